@@ -1,15 +1,29 @@
 package main
 
 import (
-	"github.com/openware/pkg/ika"
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"github.com/openware/pkg/sonic/config"
 	"github.com/openware/pkg/sonic/database"
+	"io/fs"
+	"io/ioutil"
 	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/gin-gonic/gin"
 	"github.com/openware/pkg/kli"
+	"github.com/openware/sonic/skel/assets"
+	"github.com/openware/sonic/skel/events"
 	"github.com/openware/sonic/skel/handlers"
+	"github.com/openware/sonic/skel/i18n"
 	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/secrets"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/openware/sonic/skel/sources"
 )
 
 // Version of the application displayed by the cli and the version endpoint
@@ -18,11 +32,51 @@ var Version = "1.0.0"
 // App config for the application
 var App config.Runtime
 
-func serve() error {
+// Settings of the application, App.Conf is the embedded sonic configuration
+var Settings settings.Config
+
+// Standard logger output filtered by the configured level
+var logWriter = settings.NewLogWriter(os.Stderr)
+
+// In-memory Vault used by the fake secrets driver
+var fakeVault *secrets.FakeVault
+
+// Files shipped in the binary, read instead of the working directory when embed is set
+//
+//go:embed views config/seeds config/locales public
+var embedded embed.FS
+
+// files returns the views, seeds and public files, from the binary or the working directory
+func files() fs.FS {
+	if Settings.Embed {
+		return embedded
+	}
+	return os.DirFS(".")
+}
+
+func serve(gf *globalFlags) error {
+	// Reload the configuration files when they change or on SIGHUP
+	reloader := settings.NewReloader(&Settings, func() (*settings.Config, error) {
+		cfg := &settings.Config{}
+		if _, err := settings.Load(cfg, gf.config, Settings.Env, gf.overrides); err != nil {
+			return nil, err
+		}
+		applyFakeSecrets(cfg)
+		return cfg, nil
+	}, validateConfig)
+	reloader.Subscribe(func(cfg *settings.Config) {
+		logWriter.SetLevel(cfg.Log.Level)
+	})
+	stop, err := reloader.Watch(gf.config, settings.ProfilePath(gf.config, Settings.Env))
+	if err != nil {
+		log.Printf("WARN: configuration files are not watched: %s", err.Error())
+	} else {
+		defer stop()
+	}
+
 	App.Srv = gin.Default()
-	handlers.Setup(&App)
-	App.Srv.Run(":" + App.Conf.Port)
-	return nil
+	handlers.Setup(&App, reloader, files())
+	return App.Srv.Run(":" + App.Conf.Port)
 }
 
 // boot is executed before commands
@@ -32,16 +86,164 @@ func boot() error {
 	if err != nil {
 		log.Fatal(err)
 	}
+	// The pool is only applied to mysql, every new connection to :memory: opens an empty database
+	if App.Conf.Database.Driver == "memory" && App.Conf.Database.Pool > 0 {
+		sql, err := App.DB.DB()
+		if err != nil {
+			return err
+		}
+		sql.SetMaxOpenConns(App.Conf.Database.Pool)
+	}
 	App.Version = Version
+	models.SetFiles(files())
 	models.Setup(&App)
 	return models.Migrate()
 }
 
+// Configuration values hidden by 'config print'
+var redactedConfig = []string{"jwt_private_key", "vault.token", "database.pass", "pages.preview_secret"}
+
+// Checks run by 'config validate'
+var configRules = sources.Rules{
+	"port":                    {sources.Required, sources.Port},
+	"database.driver":         {sources.Required, sources.OneOf("mysql", "memory")},
+	"database.port":           {sources.Port},
+	"redis.port":              {sources.Port},
+	"mngapi.peatio_url":       {sources.URL},
+	"mngapi.barong_url":       {sources.URL},
+	"mngapi.jwt_algo":         {sources.OneOf("RS256", "RS384", "RS512")},
+	"vault.addr":              {sources.Required, sources.URL},
+	"log.level":               {sources.OneOf(settings.LogDebug, settings.LogInfo, settings.LogWarn, settings.LogError)},
+	"daemons.sync_interval":   {sources.Positive},
+	"pages.preview_ttl":       {sources.Positive},
+	"pages.schedule_interval": {sources.Positive},
+	"seo.base_url":            {sources.URL},
+	"media.driver":            {sources.OneOf(settings.MediaLocal)},
+	"media.max_size":          {sources.Positive},
+	"routes.api":              {sources.Paths},
+	"routes.client":           {sources.Paths},
+	"deploymentID":            {sources.Required},
+	"env":                     {sources.Required},
+	"secrets.driver":          {sources.OneOf(settings.SecretsVault, settings.SecretsFake)},
+	"opendax.addr":            {sources.URL},
+}
+
+// Checks added in production, where several replicas run and restarts must not break links
+var productionRules = sources.Rules{
+	"pages.preview_secret": {sources.Required},
+}
+
+// validateConfig checks a configuration against configRules, and productionRules in production
+func validateConfig(cfg *settings.Config) []error {
+	errs := sources.Validate(cfg, configRules)
+	if cfg.Env == settings.Production {
+		errs = append(errs, sources.Validate(cfg, productionRules)...)
+	}
+	return errs
+}
+
+// Commands which don't need the database
+var noBootCommands = map[string]bool{
+	"config": true,
+	"asset":  true,
+	"i18n":   true,
+	// Secret versions only live in Vault
+	"secret": true,
+}
+
+// overrideList collects the repeated -set flags
+type overrideList []string
+
+func (o *overrideList) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overrideList) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+// globalFlags are the root flags needed to load the configuration
+type globalFlags struct {
+	config    string
+	env       string
+	overrides overrideList
+	command   string
+}
+
+// parseGlobalFlags reads the root flags and the command name,
+// kli only parses them when running the command
+func parseGlobalFlags(args []string) *globalFlags {
+	gf := &globalFlags{config: "config/app.yml"}
+
+	flags := flag.NewFlagSet("sonic", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.StringVar(&gf.config, "config", gf.config, "")
+	flags.StringVar(&gf.env, "env", "", "")
+	flags.Var(&gf.overrides, "set", "")
+	// Parsing stops at the command name, errors are reported by kli
+	flags.Parse(args)
+
+	gf.command = flags.Arg(0)
+	return gf
+}
+
+// loadConfig reads the configuration of the environment into Settings and App.Conf
+func loadConfig(gf *globalFlags) (sources.Origins, error) {
+	origins, err := settings.Load(&Settings, gf.config, settings.Env(gf.env), gf.overrides)
+	if err != nil {
+		return nil, err
+	}
+	if Settings.Secrets.Driver == settings.SecretsFake {
+		fakeVault, err = secrets.NewFakeVault()
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Using the in-memory secret store at %s", fakeVault.Addr())
+		applyFakeSecrets(&Settings)
+		origins["vault.addr"] = settings.SecretsFake
+	}
+	if err := logWriter.SetLevel(Settings.Log.Level); err != nil {
+		return nil, err
+	}
+	App.Conf = Settings.Config
+	return origins, nil
+}
+
+// applyFakeSecrets points the configuration to the in-memory Vault if it's running
+func applyFakeSecrets(cfg *settings.Config) {
+	if fakeVault == nil {
+		return
+	}
+	cfg.Vault.Addr = fakeVault.Addr()
+	if cfg.Vault.Token == "" {
+		cfg.Vault.Token = "fake"
+	}
+}
+
+// secretStore connects to the Vault secret versions of the deployment
+func secretStore() (*secrets.Store, error) {
+	return secrets.NewStore(App.Conf.Vault.Addr, App.Conf.Vault.Token, App.Conf.DeploymentID)
+}
+
+// printJSON writes indented JSON to stdout
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
 func main() {
+	log.SetOutput(logWriter)
+
 	// Create new cli
-	cnf := "config/app.yml"
+	gf := parseGlobalFlags(os.Args[1:])
+	var origins sources.Origins
+	var override string
 	cli := kli.NewCli("sonic", "Fullstack micro application", Version)
-	cli.StringFlag("config", "Application yaml configuration file", &cnf)
+	cli.StringFlag("config", "Application configuration file (yaml, json or toml)", &gf.config)
+	cli.StringFlag("env", "Environment: development, test or production, overlays config/app.<env>.yml (default $SONIC_ENV)", &gf.env)
+	cli.StringFlag("set", "Override a configuration value, f.e. -set database.host=db (repeatable)", &override)
 
 	dbCmd := cli.NewSubCommand("db", "Database commands")
 	dbCmd.NewSubCommand("create", "Create database").Action(func() error {
@@ -55,31 +257,119 @@ func main() {
 		return models.Seed()
 	})
 
+	var component, scope string
+	var from, to, version int
+	secretCmd := cli.NewSubCommand("secret", "Secret versioning commands")
+	versionsCmd := secretCmd.NewSubCommand("versions", "List versions of a component scope")
+	versionsCmd.StringFlag("component", "Component name, f.e. global", &component)
+	versionsCmd.StringFlag("scope", "Scope: public, private or secret", &scope)
+	versionsCmd.Action(func() error {
+		store, err := secretStore()
+		if err != nil {
+			return err
+		}
+		versions, err := store.ListVersions(component, scope)
+		if err != nil {
+			return err
+		}
+		return printJSON(versions)
+	})
+	diffCmd := secretCmd.NewSubCommand("diff", "Show changes between two versions of a component scope")
+	diffCmd.StringFlag("component", "Component name, f.e. global", &component)
+	diffCmd.StringFlag("scope", "Scope: public, private or secret", &scope)
+	diffCmd.IntFlag("from", "Version to compare from", &from)
+	diffCmd.IntFlag("to", "Version to compare to (latest by default)", &to)
+	diffCmd.Action(func() error {
+		store, err := secretStore()
+		if err != nil {
+			return err
+		}
+		changes, err := store.Diff(component, scope, int64(from), int64(to))
+		if err != nil {
+			return err
+		}
+		return printJSON(changes)
+	})
+	rollbackCmd := secretCmd.NewSubCommand("rollback", "Restore a previous version of a component scope")
+	rollbackCmd.StringFlag("component", "Component name, f.e. global", &component)
+	rollbackCmd.StringFlag("scope", "Scope: public, private or secret", &scope)
+	rollbackCmd.IntFlag("version", "Version to restore", &version)
+	rollbackCmd.Action(func() error {
+		store, err := secretStore()
+		if err != nil {
+			return err
+		}
+		newVersion, err := store.Rollback(component, scope, int64(version))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s.%s restored from version %d as version %d\n", component, scope, version, newVersion)
+
+		// Let running replicas refresh their cache
+		bus := events.Connect(App.Conf.Redis.Host, App.Conf.Redis.Port)
+		defer bus.Close()
+		return events.PublishConfigInvalidation(bus, component, scope)
+	})
+
+	configCmd := cli.NewSubCommand("config", "Configuration commands")
+	configCmd.NewSubCommand("print", "Print the merged configuration with the source of each value").Action(func() error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, entry := range sources.Entries(&Settings, origins, redactedConfig...) {
+			fmt.Fprintf(w, "%s\t%v\t%s\n", entry.Path, entry.Value, entry.Origin)
+		}
+		return w.Flush()
+	})
+	configCmd.NewSubCommand("validate", "Check required values, URLs and ports").Action(func() error {
+		errs := validateConfig(&Settings)
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%d invalid configuration values", len(errs))
+		}
+		fmt.Println("Configuration is valid")
+		return nil
+	})
+
+	assetCmd := cli.NewSubCommand("asset", "Frontend asset commands")
+	assetCmd.NewSubCommand("build", "Run the asset init, build and copy commands and fingerprint the output").Action(func() error {
+		_, err := assets.Build(Settings.Asset, os.Stdout)
+		return err
+	})
+	assetCmd.NewSubCommand("watch", "Build the assets and rebuild them on every change").Action(func() error {
+		return assets.Watch(Settings.Asset, os.Stdout, nil)
+	})
+
+	i18nCmd := cli.NewSubCommand("i18n", "Translation catalog commands")
+	i18nCmd.NewSubCommand("missing", "List the keys of the default locale missing in the other catalogs").Action(func() error {
+		catalogs, err := i18n.Load(files(), handlers.LocalesDir, Settings.Languages.Default)
+		if err != nil {
+			return err
+		}
+		missing := catalogs.Missing()
+		count := 0
+		for _, locale := range catalogs.Locales() {
+			for _, key := range missing[locale] {
+				fmt.Printf("%s\t%s\n", locale, key)
+				count++
+			}
+		}
+		if count > 0 {
+			return fmt.Errorf("%d missing keys", count)
+		}
+		fmt.Printf("No missing keys in %s\n", strings.Join(catalogs.Locales(), ", "))
+		return nil
+	})
+
 	serveCmd := cli.NewSubCommand("serve", "Run the application")
-	serveCmd.Action(serve)
+	serveCmd.Action(func() error {
+		return serve(gf)
+	})
 
-	// FIXME: Some issues with ika usage:
-	// 1. Can I use env only? Do I specify some magic path for that?
-	// 2. If I have same config, in env and yaml, what do I get?
-	// 3. What about more controllable usage, like
-	//
-	// type Source interface {
-	//   func Load(config interface{}) error
-	// }
-	//
-	// ReadConfig(cfg interface{}, configSource ...Source) error
-	//
-	// configs override from left to right
-	// ika.ReadConfig(
-	// 	&App.Conf,
-	// 	ika.FileSource(jsonPath, ika.DecoderForFile(path)), - autodetect for json / yaml
-	// 	ika.FileSource(tomlPath, tomlDecoder),
-	// 	ika.EnvSource(),
-	// 	myCustomSource, - f.e., config from vault
-	// );
-	//
-	// read configuration from the file and environment variables
-	if err := ika.ReadConfig(cnf, &App.Conf); err != nil {
+	// read configuration from the sources
+	var err error
+	origins, err = loadConfig(gf)
+	if err != nil {
 		log.Fatalf("Error: %v\n", err)
 	}
 
@@ -88,8 +378,10 @@ func main() {
 	// If database doesn't exist - you can not create it, because boot() will run migrations and raise an error.
 	//
 	// Due to mysql compose config - opendax_development exists by default and we don't face this issue if we just started mysql.
-	if err := boot(); err != nil {
-		log.Fatalf("Error: %v\n", err)
+	if !noBootCommands[gf.command] {
+		if err := boot(); err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
 	}
 	if err := cli.Run(); err != nil {
 		log.Fatalf("Run: %v\n", err)
@@ -0,0 +1,123 @@
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "assets")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestBuild(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	client := filepath.Join(dir, "client")
+	output := filepath.Join(dir, "public")
+	require.NoError(t, os.MkdirAll(client, 0755))
+
+	out := &bytes.Buffer{}
+	manifest, err := Build(settings.AssetConfig{
+		Init:   "echo installing",
+		Build:  "mkdir -p build && echo 'console.log(1)' > build/main.js && echo 'body{}' > build/main.css",
+		Copy:   "cp -r " + filepath.Join(client, "build") + " " + output,
+		Dir:    client,
+		Output: output,
+	}, out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "==> init: echo installing\ninstalling\n")
+	assert.Contains(t, out.String(), "==> 2 files fingerprinted")
+	assert.Len(t, manifest, 2)
+	assert.FileExists(t, filepath.Join(output, manifest["main.js"].File))
+	assert.FileExists(t, filepath.Join(output, ManifestFile))
+
+	_, err = Build(settings.AssetConfig{Build: "echo failing && exit 3", Dir: client, Output: output}, out)
+	assert.EqualError(t, err, `asset build "echo failing && exit 3": exit status 3`)
+	assert.Contains(t, out.String(), "failing\n")
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	write("main.js", "console.log(1)")
+	write("main.css", "body{}")
+	write("chunks/vendor.js", "vendor")
+	write("runtime.0123abcd.js", "runtime")
+	write("logo.png", "png")
+
+	manifest, err := Fingerprint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, Manifest{
+		"main.js":             {File: "main.0a286891.js", IsEntry: true},
+		"main.css":            {File: "main.7c98040a.css", IsEntry: true},
+		"chunks/vendor.js":    {File: "chunks/vendor.js"},
+		"runtime.0123abcd.js": {File: "runtime.0123abcd.js", IsEntry: true},
+	}, manifest)
+	assert.FileExists(t, filepath.Join(dir, "logo.png"))
+	assert.NoFileExists(t, filepath.Join(dir, "main.js"))
+	assert.FileExists(t, filepath.Join(dir, "chunks/vendor.js"))
+
+	// A new build replaces the previous files
+	write("main.js", "console.log(2)")
+	manifest, err = Fingerprint(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, "main.0a286891.js", manifest["main.js"].File)
+	assert.NoFileExists(t, filepath.Join(dir, "main.0a286891.js"))
+	assert.Equal(t, "main.7c98040a.css", manifest["main.css"].File)
+	assert.Len(t, manifest, 4)
+
+	saved, err := ReadManifest(dir)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, saved)
+}
+
+func TestCompress(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	script := strings.Repeat("console.log(1);\n", 100)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.js"), []byte(script), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "small.css"), []byte("body{}"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "logo.png"), []byte(script), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "removed.js.gz"), []byte("stale"), 0644))
+
+	count, err := Compress(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoFileExists(t, filepath.Join(dir, "small.css.gz"))
+	assert.NoFileExists(t, filepath.Join(dir, "logo.png.gz"))
+	assert.NoFileExists(t, filepath.Join(dir, "removed.js.gz"))
+
+	f, err := os.Open(filepath.Join(dir, "main.js.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	raw, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, script, string(raw))
+
+	f, err = os.Open(filepath.Join(dir, "main.js.br"))
+	require.NoError(t, err)
+	defer f.Close()
+	raw, err = ioutil.ReadAll(brotli.NewReader(f))
+	require.NoError(t, err)
+	assert.Equal(t, script, string(raw))
+}
@@ -0,0 +1,62 @@
+package assets
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/openware/sonic/skel/settings"
+)
+
+// Run executes a shell command in dir, streaming its output to out
+func Run(command, dir string, out io.Writer) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// Build runs the init, build and copy steps of the asset config, then fingerprints and compresses the output.
+// It stops at the first step exiting with a non zero status.
+func Build(cnf settings.AssetConfig, out io.Writer) (Manifest, error) {
+	if err := runStep("init", cnf.Init, cnf.Dir, out); err != nil {
+		return nil, err
+	}
+	return rebuild(cnf, out)
+}
+
+// rebuild runs the steps needed after a change of the sources
+func rebuild(cnf settings.AssetConfig, out io.Writer) (Manifest, error) {
+	if err := runStep("build", cnf.Build, cnf.Dir, out); err != nil {
+		return nil, err
+	}
+	if err := runStep("copy", cnf.Copy, ".", out); err != nil {
+		return nil, err
+	}
+
+	manifest, err := Fingerprint(cnf.Output)
+	if err != nil {
+		return nil, fmt.Errorf("asset fingerprint: %w", err)
+	}
+	fmt.Fprintf(out, "==> %d files fingerprinted in %s\n", len(manifest), cnf.Output)
+
+	compressed, err := Compress(cnf.Output)
+	if err != nil {
+		return nil, fmt.Errorf("asset compress: %w", err)
+	}
+	fmt.Fprintf(out, "==> %d files compressed in %s\n", compressed, cnf.Output)
+	return manifest, nil
+}
+
+func runStep(name, command, dir string, out io.Writer) error {
+	if command == "" {
+		fmt.Fprintf(out, "==> %s: skipped, asset.%s is empty\n", name, name)
+		return nil
+	}
+	fmt.Fprintf(out, "==> %s: %s\n", name, command)
+	if err := Run(command, dir, out); err != nil {
+		return fmt.Errorf("asset %s %q: %w", name, command, err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Manifest locations, relative to the output directory, in lookup order
+var (
+	viteManifests    = []string{".vite/manifest.json", ManifestFile}
+	webpackManifest  = "asset-manifest.json"
+	scriptExtensions = map[string]bool{".js": true, ".mjs": true}
+)
+
+// Preload is a file fetched early by the browser, As is the preload destination
+type Preload struct {
+	URL string
+	As  string
+}
+
+// Bundle lists the files loaded by the index page in order, with their URLs
+type Bundle struct {
+	Styles   []string
+	Scripts  []string
+	Preloads []Preload
+	// Module is true for ES module builds, scripts need type="module"
+	Module bool
+
+	prefix    string
+	urls      map[string]string
+	immutable map[string]bool
+}
+
+// webpackAssets is the asset-manifest.json written by webpack-manifest-plugin and create-react-app
+type webpackAssets struct {
+	Files       map[string]string `json:"files"`
+	Entrypoints []string          `json:"entrypoints"`
+}
+
+// LoadBundle reads the build manifest of dir in fsys, a Vite manifest, the one written by Fingerprint
+// or a webpack asset-manifest.json, and resolves the files of the entries in order.
+// Without entries, the entry points of the manifest are loaded in alphabetical order.
+// URLs are built from prefix, the path dir is served at.
+func LoadBundle(fsys fs.FS, dir, prefix string, entries []string) (*Bundle, error) {
+	for _, name := range viteManifests {
+		raw, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		manifest := Manifest{}
+		if err := json.Unmarshal(raw, &manifest); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return manifestBundle(manifest, prefix, entries)
+	}
+
+	raw, err := fs.ReadFile(fsys, path.Join(dir, webpackManifest))
+	if err != nil {
+		return nil, err
+	}
+	manifest := webpackAssets{}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", webpackManifest, err)
+	}
+	return webpackBundle(manifest, prefix, entries)
+}
+
+// GlobBundle loads the js and css files at the root of dir in fsys in alphabetical order,
+// it's used in development when the assets are not built
+func GlobBundle(fsys fs.FS, dir, prefix string) (*Bundle, error) {
+	b := newBundle(prefix)
+	for _, pattern := range []string{"*.css", "*.js"} {
+		matches, err := fs.Glob(fsys, path.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			b.add(path.Base(match), path.Base(match), prefix)
+		}
+	}
+	return b, nil
+}
+
+// URL returns the URL of a file by its name in the manifest, f.e. "main.js"
+func (b *Bundle) URL(name string) (string, bool) {
+	url, ok := b.urls[name]
+	return url, ok
+}
+
+// Path returns the URL of a file by its name in the manifest,
+// files which are not in the manifest are looked for under the prefix with their name
+func (b *Bundle) Path(name string) string {
+	if url, ok := b.urls[name]; ok {
+		return url
+	}
+	return path.Join(b.prefix, name)
+}
+
+// Immutable tells if the file at this URL has a content hash in its name, so it never changes
+func (b *Bundle) Immutable(url string) bool {
+	return b.immutable[url]
+}
+
+func newBundle(prefix string) *Bundle {
+	return &Bundle{
+		prefix:    prefix,
+		Styles:    []string{},
+		Scripts:   []string{},
+		Preloads:  []Preload{},
+		urls:      map[string]string{},
+		immutable: map[string]bool{},
+	}
+}
+
+// add records a file loaded by the page and appends it to the styles or the scripts
+func (b *Bundle) add(name, file, prefix string) {
+	url := path.Join(prefix, file)
+	b.urls[name] = url
+	if path.Base(file) != path.Base(name) || fingerprinted.MatchString(file) {
+		b.immutable[url] = true
+	}
+
+	switch {
+	case strings.HasSuffix(file, ".css"):
+		b.Styles = appendOnce(b.Styles, url)
+	case scriptExtensions[path.Ext(file)]:
+		b.Scripts = appendOnce(b.Scripts, url)
+	}
+}
+
+func manifestBundle(manifest Manifest, prefix string, entries []string) (*Bundle, error) {
+	b := newBundle(prefix)
+	for name, entry := range manifest {
+		b.urls[name] = path.Join(prefix, entry.File)
+		if path.Base(entry.File) != path.Base(name) || fingerprinted.MatchString(entry.File) {
+			b.immutable[b.urls[name]] = true
+		}
+		for _, css := range entry.CSS {
+			b.immutable[path.Join(prefix, css)] = true
+		}
+		if entry.Src != "" {
+			b.Module = true
+		}
+	}
+
+	if len(entries) == 0 {
+		for name, entry := range manifest {
+			if entry.IsEntry {
+				entries = append(entries, name)
+			}
+		}
+		sort.Strings(entries)
+	}
+
+	preloaded := map[string]bool{}
+	var visit func(name string, entry bool) error
+	visit = func(name string, entry bool) error {
+		e, ok := manifest[name]
+		if !ok {
+			return fmt.Errorf("asset %q is not in the manifest", name)
+		}
+		// Chunks imported by the entries are preloaded, their styles loaded before the entry ones
+		for _, imported := range e.Imports {
+			if preloaded[imported] {
+				continue
+			}
+			preloaded[imported] = true
+			if err := visit(imported, false); err != nil {
+				return err
+			}
+		}
+		for _, css := range e.CSS {
+			b.Styles = appendOnce(b.Styles, path.Join(prefix, css))
+		}
+
+		url := path.Join(prefix, e.File)
+		switch {
+		case strings.HasSuffix(e.File, ".css"):
+			b.Styles = appendOnce(b.Styles, url)
+		case !entry:
+			as := "script"
+			if b.Module {
+				as = "modulepreload"
+			}
+			b.Preloads = append(b.Preloads, Preload{URL: url, As: as})
+		case scriptExtensions[path.Ext(e.File)]:
+			b.Scripts = appendOnce(b.Scripts, url)
+		}
+		return nil
+	}
+	for _, name := range entries {
+		if err := visit(name, true); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func webpackBundle(manifest webpackAssets, prefix string, entries []string) (*Bundle, error) {
+	b := newBundle(prefix)
+	files := map[string]string{}
+	for name, file := range manifest.Files {
+		file = strings.TrimPrefix(file, "/")
+		files[name] = file
+		b.urls[name] = path.Join(prefix, file)
+		if path.Base(file) != path.Base(name) || fingerprinted.MatchString(file) {
+			b.immutable[b.urls[name]] = true
+		}
+	}
+
+	if len(entries) == 0 {
+		for _, file := range manifest.Entrypoints {
+			b.add(file, file, prefix)
+		}
+		return b, nil
+	}
+	for _, name := range entries {
+		file, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("asset %q is not in %s", name, webpackManifest)
+		}
+		b.add(name, file, prefix)
+	}
+	return b, nil
+}
+
+func appendOnce(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
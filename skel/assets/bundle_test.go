@@ -0,0 +1,109 @@
+package assets
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBundleVite(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".vite"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".vite/manifest.json"), []byte(`{
+		"src/main.tsx": {"file": "assets/main-4f3a2b1c.js", "src": "src/main.tsx", "isEntry": true,
+			"css": ["assets/main-9a8b7c6d.css"], "imports": ["_vendor-11aa22bb.js"]},
+		"src/admin.tsx": {"file": "assets/admin-55cc66dd.js", "src": "src/admin.tsx", "isEntry": true,
+			"imports": ["_vendor-11aa22bb.js"]},
+		"_vendor-11aa22bb.js": {"file": "assets/vendor-11aa22bb.js", "css": ["assets/vendor-33ee44ff.css"]},
+		"logo.svg": {"file": "assets/logo-77aa88bb.svg"}
+	}`), 0644))
+
+	b, err := LoadBundle(os.DirFS(dir), ".", "/public/assets", []string{"src/main.tsx", "src/admin.tsx"})
+	require.NoError(t, err)
+	assert.True(t, b.Module)
+	assert.Equal(t, []string{"/public/assets/assets/vendor-33ee44ff.css", "/public/assets/assets/main-9a8b7c6d.css"}, b.Styles)
+	assert.Equal(t, []string{"/public/assets/assets/main-4f3a2b1c.js", "/public/assets/assets/admin-55cc66dd.js"}, b.Scripts)
+	assert.Equal(t, []Preload{{URL: "/public/assets/assets/vendor-11aa22bb.js", As: "modulepreload"}}, b.Preloads)
+
+	url, ok := b.URL("logo.svg")
+	assert.True(t, ok)
+	assert.Equal(t, "/public/assets/assets/logo-77aa88bb.svg", url)
+	assert.True(t, b.Immutable(url))
+	assert.True(t, b.Immutable("/public/assets/assets/main-9a8b7c6d.css"))
+	assert.False(t, b.Immutable("/public/assets/manifest.json"))
+
+	// Entries default to the entry points in alphabetical order
+	b, err = LoadBundle(os.DirFS(dir), ".", "/public/assets", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/public/assets/assets/admin-55cc66dd.js", "/public/assets/assets/main-4f3a2b1c.js"}, b.Scripts)
+
+	_, err = LoadBundle(os.DirFS(dir), ".", "/public/assets", []string{"src/missing.tsx"})
+	assert.Error(t, err)
+}
+
+func TestLoadBundleFingerprint(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "runtime.js"), []byte("runtime"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.js"), []byte("main"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.css"), []byte("body{}"), 0644))
+	manifest, err := Fingerprint(dir)
+	require.NoError(t, err)
+
+	b, err := LoadBundle(os.DirFS(dir), ".", "/public/assets", []string{"runtime.js", "main.css", "main.js"})
+	require.NoError(t, err)
+	assert.False(t, b.Module)
+	assert.Equal(t, []string{"/public/assets/" + manifest["main.css"].File}, b.Styles)
+	assert.Equal(t, []string{"/public/assets/" + manifest["runtime.js"].File, "/public/assets/" + manifest["main.js"].File}, b.Scripts)
+	assert.True(t, b.Immutable(b.Scripts[0]))
+}
+
+func TestLoadBundleWebpack(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "asset-manifest.json"), []byte(`{
+		"files": {
+			"main.css": "/static/css/main.1a2b3c4d.css",
+			"main.js": "/static/js/main.5e6f7a8b.js",
+			"index.html": "/index.html"
+		},
+		"entrypoints": ["static/css/main.1a2b3c4d.css", "static/js/main.5e6f7a8b.js"]
+	}`), 0644))
+
+	b, err := LoadBundle(os.DirFS(dir), ".", "/public/assets", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/public/assets/static/css/main.1a2b3c4d.css"}, b.Styles)
+	assert.Equal(t, []string{"/public/assets/static/js/main.5e6f7a8b.js"}, b.Scripts)
+	assert.True(t, b.Immutable("/public/assets/static/js/main.5e6f7a8b.js"))
+	assert.False(t, b.Immutable("/public/assets/index.html"))
+
+	url, ok := b.URL("main.js")
+	assert.True(t, ok)
+	assert.Equal(t, "/public/assets/static/js/main.5e6f7a8b.js", url)
+	assert.Equal(t, url, b.Path("main.js"))
+	assert.Equal(t, "/public/assets/favicon.ico", b.Path("favicon.ico"))
+}
+
+func TestGlobBundle(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"b.js", "a.js", "style.css"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	_, err := LoadBundle(os.DirFS(dir), ".", "/public/assets", nil)
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	b, err := GlobBundle(os.DirFS(dir), ".", "/public/assets")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/public/assets/style.css"}, b.Styles)
+	assert.Equal(t, []string{"/public/assets/a.js", "/public/assets/b.js"}, b.Scripts)
+	assert.False(t, b.Immutable("/public/assets/a.js"))
+}
@@ -0,0 +1,134 @@
+package assets
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// MinCompressSize is the size under which files are not compressed, the gain doesn't pay the decoding
+const MinCompressSize = 1024
+
+// Encoding is a precompressed sibling written by Compress, Suffix is appended to the file name
+// and Name is the Content-Encoding it's served with
+type Encoding struct {
+	Suffix string
+	Name   string
+}
+
+// Encodings lists the precompressed siblings in order of preference
+var Encodings = []Encoding{
+	{Suffix: ".br", Name: "br"},
+	{Suffix: ".gz", Name: "gzip"},
+}
+
+// compressible are the extensions of text files, images and fonts are already compressed
+var compressible = map[string]bool{
+	".js":   true,
+	".mjs":  true,
+	".css":  true,
+	".html": true,
+	".json": true,
+	".map":  true,
+	".svg":  true,
+	".txt":  true,
+	".xml":  true,
+	".wasm": true,
+}
+
+// Compress writes a brotli .br and a gzip .gz sibling for the text files of dir larger than MinCompressSize.
+// Siblings newer than their file are kept, the ones of removed files are deleted.
+// It returns the number of files which have compressed siblings.
+func Compress(dir string) (int, error) {
+	count := 0
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if isEncoding(ext) {
+			if !fileExists(strings.TrimSuffix(path, ext)) {
+				return os.Remove(path)
+			}
+			return nil
+		}
+		if !compressible[ext] || info.Size() < MinCompressSize {
+			return nil
+		}
+
+		written := 0
+		for _, encoding := range Encodings {
+			ok, err := compressFile(path, encoding.Suffix, info)
+			if err != nil {
+				return err
+			}
+			if ok {
+				written++
+			}
+		}
+		if written > 0 {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+func isEncoding(ext string) bool {
+	for _, encoding := range Encodings {
+		if encoding.Suffix == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// compressFile writes the sibling of path with the encoding of suffix, unless it's up to date.
+// Compressed files which are not smaller than the original are not kept.
+func compressFile(path, suffix string, info os.FileInfo) (bool, error) {
+	target := path + suffix
+	if current, err := os.Stat(target); err == nil && !current.ModTime().Before(info.ModTime()) {
+		return true, nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+	dst, err := os.Create(target)
+	if err != nil {
+		return false, err
+	}
+
+	var w io.WriteCloser
+	if suffix == ".br" {
+		w = brotli.NewWriterLevel(dst, brotli.BestCompression)
+	} else {
+		w, _ = gzip.NewWriterLevel(dst, gzip.BestCompression)
+	}
+	_, err = io.Copy(w, src)
+	if err == nil {
+		err = w.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return false, err
+	}
+
+	compressed, err := os.Stat(target)
+	if err != nil {
+		return false, err
+	}
+	if compressed.Size() >= info.Size() {
+		return false, os.Remove(target)
+	}
+	return true, nil
+}
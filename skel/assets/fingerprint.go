@@ -0,0 +1,155 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ManifestFile is written in the output directory by Fingerprint
+const ManifestFile = "manifest.json"
+
+// hashLength is the number of hex characters of the content hash added to file names
+const hashLength = 8
+
+// fingerprinted matches names which already carry a content hash, f.e. main.3f2a9c1b.js
+var fingerprinted = regexp.MustCompile(`\.[0-9a-f]{8,}\.[^.]+$`)
+
+// ManifestEntry describes a built file, the format is a subset of the Vite manifest
+type ManifestEntry struct {
+	File    string   `json:"file"`
+	Src     string   `json:"src,omitempty"`
+	IsEntry bool     `json:"isEntry,omitempty"`
+	CSS     []string `json:"css,omitempty"`
+	Imports []string `json:"imports,omitempty"`
+}
+
+// Manifest maps the original name of each file to its fingerprinted name
+type Manifest map[string]ManifestEntry
+
+// Fingerprint adds a content hash to the name of the js and css entries of dir
+// and writes the mapping to dir/manifest.json. Chunks keep their name since
+// the bundle loads them by it, and so do other files since stylesheets
+// reference them with relative URLs.
+// Files fingerprinted by a previous run and rebuilt since are removed.
+func Fingerprint(dir string) (Manifest, error) {
+	previous, err := ReadManifest(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	manifest := Manifest{}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		ext := filepath.Ext(name)
+		if ext != ".js" && ext != ".css" {
+			return nil
+		}
+		if !isEntry(name) {
+			manifest[name] = ManifestEntry{File: name}
+			return nil
+		}
+
+		// Keep the hashes of bundlers and of the previous runs
+		if fingerprinted.MatchString(name) {
+			if !isKnown(previous, name) {
+				manifest[name] = ManifestEntry{File: name, IsEntry: isEntry(name)}
+			}
+			return nil
+		}
+
+		hash, err := fileHash(path)
+		if err != nil {
+			return err
+		}
+		file := strings.TrimSuffix(name, ext) + "." + hash + ext
+		if err := os.Rename(path, filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			return err
+		}
+		manifest[name] = ManifestEntry{File: file, IsEntry: isEntry(name)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Files of the previous run are removed once rebuilt, the others are kept
+	for name, entry := range previous {
+		if current, ok := manifest[name]; ok && current.File == entry.File {
+			continue
+		}
+		if _, ok := manifest[name]; !ok && fileExists(filepath.Join(dir, filepath.FromSlash(entry.File))) {
+			manifest[name] = entry
+			continue
+		}
+		os.Remove(filepath.Join(dir, filepath.FromSlash(entry.File)))
+	}
+
+	return manifest, manifest.Write(dir)
+}
+
+// ReadManifest reads dir/manifest.json
+func ReadManifest(dir string) (Manifest, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	manifest := Manifest{}
+	return manifest, json.Unmarshal(raw, &manifest)
+}
+
+// Write saves the manifest to dir/manifest.json
+func (m Manifest) Write(dir string) error {
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, ManifestFile), raw, 0644)
+}
+
+// isKnown tells if name is the fingerprinted file of an entry
+func isKnown(manifest Manifest, name string) bool {
+	for _, entry := range manifest {
+		if entry.File == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isEntry tells if the file is loaded by the index page, chunks in sub directories are loaded by the bundle
+func isEntry(name string) bool {
+	return !strings.Contains(name, "/")
+}
+
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:hashLength], nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
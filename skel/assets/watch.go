@@ -0,0 +1,87 @@
+package assets
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/openware/sonic/skel/settings"
+)
+
+// rebuildDelay groups the changes of a save or a checkout in a single build
+const rebuildDelay = 500 * time.Millisecond
+
+// ignoredDirs are never watched, they hold dependencies or build outputs
+var ignoredDirs = map[string]bool{
+	"node_modules": true,
+	"build":        true,
+	"dist":         true,
+}
+
+// Watch runs Build, then rebuilds every time a file of the asset directory changes until done is closed.
+// Failed rebuilds are reported to out and the watch goes on.
+func Watch(cnf settings.AssetConfig, out io.Writer, done <-chan struct{}) error {
+	if _, err := Build(cnf, out); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	output, _ := filepath.Abs(cnf.Output)
+	add := func(root string) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				return err
+			}
+			abs, _ := filepath.Abs(path)
+			if path != root && (ignoredDirs[info.Name()] || strings.HasPrefix(info.Name(), ".")) || abs == output {
+				return filepath.SkipDir
+			}
+			return watcher.Add(path)
+		})
+	}
+	if err := add(cnf.Dir); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "==> watching %s\n", cnf.Dir)
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-done:
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if strings.HasPrefix(filepath.Base(event.Name), ".") {
+				continue
+			}
+			// New directories are watched as well
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !ignoredDirs[info.Name()] {
+					add(event.Name)
+				}
+			}
+			pending = time.After(rebuildDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(out, "==> watch error: %s\n", err)
+		case <-pending:
+			pending = nil
+			if _, err := rebuild(cnf, out); err != nil {
+				fmt.Fprintf(out, "==> %s\n", err)
+			}
+		}
+	}
+}
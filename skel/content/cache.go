@@ -0,0 +1,50 @@
+package content
+
+import "sync"
+
+// Cache keeps the rendered content of each page for its current version,
+// an entry is replaced when a newer version is rendered
+type Cache struct {
+	mutex   sync.RWMutex
+	entries map[uint]cacheEntry
+}
+
+type cacheEntry struct {
+	version  uint
+	rendered Rendered
+}
+
+// NewCache creates an empty cache
+func NewCache() *Cache {
+	return &Cache{entries: map[uint]cacheEntry{}}
+}
+
+// Get returns the rendered content of the page id at version, render is called on a miss
+// and its result stored unless it fails
+func (c *Cache) Get(id, version uint, render func() (Rendered, error)) (Rendered, error) {
+	c.mutex.RLock()
+	entry, ok := c.entries[id]
+	c.mutex.RUnlock()
+	if ok && entry.version == version {
+		return entry.rendered, nil
+	}
+
+	rendered, err := render()
+	if err != nil {
+		return Rendered{}, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// A concurrent request may have rendered a newer version already
+	if current, ok := c.entries[id]; !ok || current.version <= version {
+		c.entries[id] = cacheEntry{version: version, rendered: rendered}
+	}
+	return rendered, nil
+}
+
+// Delete forgets the rendered content of the page id
+func (c *Cache) Delete(id uint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, id)
+}
@@ -0,0 +1,115 @@
+package content
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	rendered, err := Render(FormatMarkdown, "# Terms\n\n## Use *of* data\n\n<script>alert(1)</script>\n\n[click](javascript:alert(1))\n\n## Terms")
+	require.NoError(t, err)
+	html := string(rendered.HTML)
+	assert.Contains(t, html, `<h1 id="terms">Terms <a class="anchor" href="#terms" aria-hidden="true">#</a></h1>`)
+	assert.Contains(t, html, `<h2 id="use-of-data">Use <em>of</em> data`)
+	assert.NotContains(t, html, "<script>")
+	assert.NotContains(t, html, "javascript:")
+	assert.Equal(t, []Heading{
+		{Level: 1, ID: "terms", Text: "Terms"},
+		{Level: 2, ID: "use-of-data", Text: "Use of data"},
+		{Level: 2, ID: "terms-1", Text: "Terms"},
+	}, rendered.TOC)
+}
+
+func TestRenderFormats(t *testing.T) {
+	rendered, err := Render(FormatHTML, `<h2 id="fees">Fees</h2><p onclick="x()">hi <b>there</b></p><script>alert(1)</script><iframe src=//evil></iframe><a href="javascript:alert(1)">link</a>`)
+	require.NoError(t, err)
+	assert.Equal(t, `<h2 id="fees">Fees</h2><p>hi <b>there</b></p>link`, string(rendered.HTML))
+
+	rendered, err = Render(FormatPlain, "a <b>\nline\n\n\nnext")
+	require.NoError(t, err)
+	assert.Equal(t, "<p>a &lt;b&gt;<br>\nline</p>\n<p>next</p>\n", string(rendered.HTML))
+
+	_, err = Render("rst", "")
+	assert.EqualError(t, err, `unknown content format "rst"`)
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache()
+	calls := 0
+	render := func(body string) func() (Rendered, error) {
+		return func() (Rendered, error) {
+			calls++
+			return Render(FormatPlain, body)
+		}
+	}
+
+	r, err := cache.Get(1, 1, render("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>v1</p>\n", string(r.HTML))
+	r, _ = cache.Get(1, 1, render("ignored"))
+	assert.Equal(t, "<p>v1</p>\n", string(r.HTML))
+	assert.Equal(t, 1, calls)
+
+	r, _ = cache.Get(1, 2, render("v2"))
+	assert.Equal(t, "<p>v2</p>\n", string(r.HTML))
+	assert.Equal(t, 2, calls)
+
+	_, err = cache.Get(2, 1, func() (Rendered, error) { return Rendered{}, errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	cache.Delete(1)
+	cache.Get(1, 2, render("v2"))
+	assert.Equal(t, 3, calls)
+}
+
+func TestDiff(t *testing.T) {
+	assert.Equal(t, "", Diff("a", "b", "same\ntext\n", "same\ntext"))
+
+	from := "title\n\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	to := "Title\n\n1\n2\n3\n4\n5\n6\nseven\n8\n9\n10\n11\n"
+	assert.Equal(t, `--- v1
++++ v2
+@@ -1,4 +1,4 @@
+-title
++Title
+ 
+ 1
+ 2
+@@ -6,7 +6,8 @@
+ 4
+ 5
+ 6
+-7
++seven
+ 8
+ 9
+ 10
++11
+`, Diff("v1", "v2", from, to))
+
+	assert.Equal(t, "--- v1\n+++ v2\n@@ -0,0 +1 @@\n+new\n", Diff("v1", "v2", "", "new"))
+	assert.Equal(t, "--- v1\n+++ v2\n@@ -1,2 +1 @@\n-a\n b\n", Diff("v1", "v2", "a\nb", "b"))
+}
+
+func TestSearchText(t *testing.T) {
+	assert.Equal(t, []string{"trading", "fees", "2021", "комиссии"}, Terms("Trading fees, FEES 2021 — комиссии"))
+	assert.Equal(t, []string{}, Terms(" *** "))
+
+	assert.Equal(t, "Fees Maker fees are low. See the list", Text(FormatMarkdown, "# Fees\n\n**Maker** fees are _low_.\n\nSee [the list](/fees/list)"))
+	assert.Equal(t, "Fees Tom & Jerry", Text(FormatHTML, "<h1>Fees</h1><script>var fees = 1</script><p>Tom &amp; Jerry</p>"))
+	assert.Equal(t, "a b", Text(FormatPlain, " a\n\n b "))
+
+	hits, all := Hits("Trading fees: the fee of trades", []string{"trad", "fee"})
+	assert.Equal(t, 4, hits)
+	assert.True(t, all)
+	_, all = Hits("Trading fees", []string{"trad", "withdraw"})
+	assert.False(t, all)
+
+	assert.Equal(t, `<mark>Fees</mark> &amp; <mark>Trading</mark> &lt;b&gt;`, string(Highlight("Fees & Trading <b>", []string{"fee", "trad"})))
+	text := "one two three four five six seven eight nine ten fees eleven twelve thirteen fourteen"
+	assert.Equal(t, "…ten <mark>fees</mark> eleven twelve…", string(Snippet(text, []string{"fee"}, 4)))
+	assert.Equal(t, "one two three…", string(Snippet(text, []string{"withdraw"}, 3)))
+	assert.Equal(t, "…thirteen <mark>fourteen</mark>", string(Snippet(text, []string{"fourteen"}, 2)))
+}
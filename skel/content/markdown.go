@@ -0,0 +1,121 @@
+// Package content renders the text of pages
+package content
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Content formats
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPlain    = "plain"
+)
+
+// Formats lists the supported content formats
+var Formats = []string{FormatMarkdown, FormatHTML, FormatPlain}
+
+// htmlPolicy keeps the formatting elements of HTML contents, scripts, styles, frames and event handlers are dropped
+var htmlPolicy = bluemonday.UGCPolicy()
+
+// Heading is an entry of the table of contents, ID is the anchor of the heading
+type Heading struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+// Rendered is the HTML of a content with the table of contents of its headings
+type Rendered struct {
+	HTML template.HTML
+	TOC  []Heading
+}
+
+// Render converts body to HTML according to its format, markdown by default.
+// HTML contents are sanitized with an allow-list of elements and attributes,
+// plain text is escaped and split in paragraphs.
+func Render(format, body string) (Rendered, error) {
+	switch format {
+	case FormatMarkdown, "":
+		return renderMarkdown(body), nil
+	case FormatHTML:
+		return Rendered{HTML: template.HTML(htmlPolicy.Sanitize(body))}, nil
+	case FormatPlain:
+		return Rendered{HTML: renderPlain(body)}, nil
+	}
+	return Rendered{}, fmt.Errorf("unknown content format %q", format)
+}
+
+// Markdown renders markdown to HTML. Raw HTML is dropped and links only use safe protocols,
+// so the result can be inserted in a page as is. Headings get an id and an anchor link.
+func Markdown(src string) template.HTML {
+	return renderMarkdown(src).HTML
+}
+
+func renderMarkdown(src string) Rendered {
+	p := parser.NewWithExtensions(parser.CommonExtensions | parser.AutoHeadingIDs)
+	doc := p.Parse([]byte(src))
+
+	toc := []Heading{}
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if heading, ok := node.(*ast.Heading); ok && entering && heading.HeadingID != "" {
+			toc = append(toc, Heading{Level: heading.Level, ID: heading.HeadingID, Text: nodeText(heading)})
+		}
+		return ast.GoToNext
+	})
+
+	r := html.NewRenderer(html.RendererOptions{
+		Flags:          html.CommonFlags | html.SkipHTML | html.Safelink | html.NofollowLinks,
+		RenderNodeHook: headingAnchor,
+	})
+	return Rendered{
+		HTML: template.HTML(markdown.Render(doc, r)),
+		TOC:  toc,
+	}
+}
+
+// headingAnchor adds a link to the heading before its closing tag, the default rendering goes on
+func headingAnchor(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	if heading, ok := node.(*ast.Heading); ok && !entering && heading.HeadingID != "" {
+		fmt.Fprintf(w, ` <a class="anchor" href="#%s" aria-hidden="true">#</a>`, template.HTMLEscapeString(heading.HeadingID))
+	}
+	return ast.GoToNext, false
+}
+
+// nodeText returns the text of a node without formatting
+func nodeText(node ast.Node) string {
+	var b strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if leaf := n.AsLeaf(); leaf != nil && entering {
+			b.Write(leaf.Literal)
+		}
+		return ast.GoToNext
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// renderPlain escapes the text, blank lines separate paragraphs and line breaks are kept
+func renderPlain(src string) template.HTML {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i := range lines {
+			lines[i] = template.HTMLEscapeString(lines[i])
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+	return template.HTML(b.String())
+}
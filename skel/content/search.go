@@ -0,0 +1,191 @@
+package content
+
+import (
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// MaxTerms bounds the words of a search query, the next ones are ignored
+const MaxTerms = 8
+
+// Terms returns the distinct lowercase words of a search query, words are letters and digits
+func Terms(query string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+	for _, span := range wordSpans(query) {
+		term := strings.ToLower(query[span[0]:span[1]])
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == MaxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// Text returns the words of a body without its markup, separated by single spaces
+func Text(format, body string) string {
+	switch format {
+	case FormatMarkdown, "":
+		doc := parser.NewWithExtensions(parser.CommonExtensions).Parse([]byte(body))
+		var b strings.Builder
+		ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+			if leaf := node.AsLeaf(); leaf != nil && entering {
+				b.Write(leaf.Literal)
+			}
+			// Blocks and line breaks separate words, inline formatting doesn't
+			switch node.(type) {
+			case *ast.Paragraph, *ast.Heading, *ast.ListItem, *ast.TableCell, *ast.CodeBlock, *ast.Softbreak, *ast.Hardbreak:
+				if !entering || node.AsLeaf() != nil {
+					b.WriteByte(' ')
+				}
+			}
+			return ast.GoToNext
+		})
+		body = b.String()
+	case FormatHTML:
+		body = html.UnescapeString(stripTags(body))
+	}
+	return strings.Join(strings.Fields(body), " ")
+}
+
+// stripTags replaces the tags of an HTML document by spaces, the content of scripts and styles is dropped
+func stripTags(src string) string {
+	var b strings.Builder
+	lower := strings.ToLower(src)
+	for i := 0; i < len(src); {
+		if src[i] != '<' {
+			b.WriteByte(src[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(src[i:], '>')
+		if end < 0 {
+			break
+		}
+		tag := lower[i : i+end+1]
+		i += end + 1
+		for _, name := range []string{"script", "style"} {
+			if strings.HasPrefix(tag, "<"+name) {
+				if closing := strings.Index(lower[i:], "</"+name); closing >= 0 {
+					i += closing
+				} else {
+					i = len(src)
+				}
+			}
+		}
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// Hits returns the number of words of text starting with one of the terms, and tells if every term starts one
+func Hits(text string, terms []string) (int, bool) {
+	found := make([]bool, len(terms))
+	hits := 0
+	for _, span := range wordSpans(text) {
+		word := strings.ToLower(text[span[0]:span[1]])
+		for i, term := range terms {
+			if strings.HasPrefix(word, term) {
+				found[i] = true
+				hits++
+				break
+			}
+		}
+	}
+	for _, ok := range found {
+		if !ok {
+			return hits, false
+		}
+	}
+	return hits, true
+}
+
+// Highlight escapes text and puts the words starting with one of the terms in <mark>
+func Highlight(text string, terms []string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, span := range wordSpans(text) {
+		if !matchesTerm(text[span[0]:span[1]], terms) {
+			continue
+		}
+		b.WriteString(template.HTMLEscapeString(text[last:span[0]]))
+		b.WriteString("<mark>" + template.HTMLEscapeString(text[span[0]:span[1]]) + "</mark>")
+		last = span[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+	return template.HTML(b.String())
+}
+
+// Snippet returns the highlighted passage of n words of text around its first match,
+// an ellipsis replaces the words cut before and after
+func Snippet(text string, terms []string, n int) template.HTML {
+	spans := wordSpans(text)
+	if len(spans) <= n {
+		return Highlight(text, terms)
+	}
+
+	first := 0
+	for i, span := range spans {
+		if matchesTerm(text[span[0]:span[1]], terms) {
+			first = i
+			break
+		}
+	}
+	// The passage starts a few words before the match to give it some context
+	start := first - n/4
+	if start < 0 {
+		start = 0
+	}
+	if start+n > len(spans) {
+		start = len(spans) - n
+	}
+	end := start + n
+
+	from, to := 0, len(text)
+	prefix, suffix := template.HTML(""), template.HTML("")
+	if start > 0 {
+		from, prefix = spans[start][0], "…"
+	}
+	if end < len(spans) {
+		to, suffix = spans[end-1][1], "…"
+	}
+	return prefix + Highlight(text[from:to], terms) + suffix
+}
+
+func matchesTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordSpans returns the byte offsets of the runs of letters and digits of text
+func wordSpans(text string) [][2]int {
+	spans := [][2]int{}
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
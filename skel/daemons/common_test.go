@@ -7,7 +7,7 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
-	"github.com/openware/pkg/ika"
+	"github.com/openware/sonic/skel/settings"
 )
 
 const (
@@ -28,13 +28,12 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-// initApp helper for initializing App database
+// initApp helper for initializing App with the test profile
 func initApp() *config.Runtime {
-	app := &config.Runtime{}
-	cnf := "../config/app.yml"
-	if err := ika.ReadConfig(cnf, &app.Conf); err != nil {
+	cnf := settings.Config{}
+	if _, err := settings.Load(&cnf, "../config/app.yml", settings.Test, nil); err != nil {
 		log.Fatalf("Error: %v\n", err)
 	}
 
-	return app
+	return &config.Runtime{Conf: cnf.Config}
 }
@@ -17,6 +17,8 @@ import (
 	"github.com/openware/kaigara/pkg/vault"
 	"github.com/openware/pkg/mngapi"
 	"github.com/openware/pkg/mngapi/peatio"
+	"github.com/openware/sonic/skel/secrets"
+	"github.com/openware/sonic/skel/settings"
 )
 
 // Define response data
@@ -62,17 +64,31 @@ type Response struct {
 	Markets    []MarketResponse   `json:"markets"`
 }
 
-func FetchConfigurationPeriodic(peatioClient *peatio.Client, vaultService *vault.Service, opendaxAddr string) {
+// FetchConfigurationPeriodic syncs the configuration every daemons.sync_interval,
+// and right after a configuration reload
+func FetchConfigurationPeriodic(peatioClient *peatio.Client, vaultService *vault.Service, reloader *settings.Reloader) {
+	reloaded := make(chan struct{}, 1)
+	reloader.Subscribe(func(cfg *settings.Config) {
+		select {
+		case reloaded <- struct{}{}:
+		default:
+		}
+	})
+
 	for {
+		cnf := reloader.Current()
 		platformID, err := getPlatformIDFromVault(vaultService)
 		if err != nil {
 			log.Printf("ERR: FetchMarkets: %v", err.Error())
 		} else {
-			if shouldRestart, err := fetchConfiguration(peatioClient, opendaxAddr, platformID); err == nil && shouldRestart {
+			if shouldRestart, err := fetchConfiguration(peatioClient, cnf.Opendax.Addr, platformID); err == nil && shouldRestart {
 				go setFinexRestart(vaultService, time.Now().Unix())
 			}
 		}
-		<-time.After(5 * time.Minute)
+		select {
+		case <-time.After(cnf.Daemons.SyncInterval):
+		case <-reloaded:
+		}
 	}
 }
 func fetchConfiguration(peatioClient *peatio.Client, opendaxAddr, platformID string) (bool, error) {
@@ -398,6 +414,9 @@ func getWallet(peatioClient *peatio.Client, wallet *peatio.Wallet) (*peatio.Wall
 }
 
 func GetXLNEnabledFromVault(vaultService *vault.Service) (bool, error) {
+	secrets.VaultLock.Lock()
+	defer secrets.VaultLock.Unlock()
+
 	app := "sonic"
 	scope := "private"
 	key := "xln_enabled"
@@ -417,6 +436,9 @@ func GetXLNEnabledFromVault(vaultService *vault.Service) (bool, error) {
 	return result.(bool), nil
 }
 func setFinexRestart(vaultService *vault.Service, timestamp int64) error {
+	secrets.VaultLock.Lock()
+	defer secrets.VaultLock.Unlock()
+
 	app := "finex"
 	scope := "private"
 
@@ -438,6 +460,9 @@ func setFinexRestart(vaultService *vault.Service, timestamp int64) error {
 	return nil
 }
 func getFinexRestart(vaultService *vault.Service) (int64, error) {
+	secrets.VaultLock.Lock()
+	defer secrets.VaultLock.Unlock()
+
 	app := "finex"
 	scope := "private"
 
@@ -15,6 +15,8 @@ import (
 
 	jwtgo "github.com/dgrijalva/jwt-go"
 	"github.com/openware/kaigara/pkg/vault"
+	"github.com/openware/sonic/skel/secrets"
+	"github.com/openware/sonic/skel/settings"
 )
 
 // LicenseResponse to store response from api
@@ -51,7 +53,7 @@ func parseLicense(lic string) (int64, int64, error) {
 }
 
 // LicenseRenewal to periodic check and renew license before expire
-func LicenseRenewal(appName string, app *sonic.Runtime, vaultService *vault.Service) {
+func LicenseRenewal(appName string, reloader *settings.Reloader, vaultService *vault.Service) {
 	for {
 		for {
 			lic, err := getLicenseFromVault(appName, vaultService)
@@ -77,7 +79,7 @@ func LicenseRenewal(appName string, app *sonic.Runtime, vaultService *vault.Serv
 				break
 			}
 
-			err = CreateNewLicense(appName, &app.Conf.Opendax, vaultService)
+			err = CreateNewLicense(appName, &reloader.Current().Opendax, vaultService)
 			if err != nil {
 				log.Println(err.Error())
 				break
@@ -165,6 +167,9 @@ func CreateNewLicense(appName string, opendaxConfig *sonic.OpendaxConfig, vaultS
 }
 
 func getPlatformIDFromVault(vaultService *vault.Service) (string, error) {
+	secrets.VaultLock.Lock()
+	defer secrets.VaultLock.Unlock()
+
 	app := "peatio"
 	scope := "private"
 	key := "platform_id"
@@ -186,6 +191,9 @@ func getPlatformIDFromVault(vaultService *vault.Service) (string, error) {
 }
 
 func getPrivateKeyFromVault(vaultService *vault.Service) (string, error) {
+	secrets.VaultLock.Lock()
+	defer secrets.VaultLock.Unlock()
+
 	app := "sonic"
 	scope := "secret"
 	key := "jwt_private_key"
@@ -203,6 +211,9 @@ func getPrivateKeyFromVault(vaultService *vault.Service) (string, error) {
 }
 
 func getLicenseFromVault(app string, vaultService *vault.Service) (string, error) {
+	secrets.VaultLock.Lock()
+	defer secrets.VaultLock.Unlock()
+
 	scope := "secret"
 
 	// Load secret
@@ -223,6 +234,9 @@ func getLicenseFromVault(app string, vaultService *vault.Service) (string, error
 }
 
 func saveLicenseToVault(app string, vaultService *vault.Service, license string) error {
+	secrets.VaultLock.Lock()
+	defer secrets.VaultLock.Unlock()
+
 	scope := "secret"
 
 	// Load secret
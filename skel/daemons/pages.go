@@ -0,0 +1,32 @@
+package daemons
+
+import (
+	"log"
+	"time"
+
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+)
+
+// PublishScheduledPages publishes the scheduled pages and archives the expired ones when they are due.
+// It wakes up at the next planned change, or after the schedule interval to see the pages saved meanwhile.
+func PublishScheduledPages(reloader *settings.Reloader) {
+	for {
+		now := time.Now()
+		changed, err := models.ApplySchedule(now)
+		if err != nil {
+			log.Printf("ERR: PublishScheduledPages: %s", err)
+		} else if changed > 0 {
+			log.Printf("PublishScheduledPages: %d pages changed status", changed)
+		}
+
+		wait := reloader.Current().Pages.ScheduleInterval
+		next, err := models.NextScheduleChange(now)
+		if err != nil {
+			log.Printf("ERR: PublishScheduledPages: %s", err)
+		} else if next != nil && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+		time.Sleep(wait)
+	}
+}
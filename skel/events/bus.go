@@ -0,0 +1,135 @@
+package events
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Handler is called for every message received on a subscribed channel
+type Handler func(payload string)
+
+// Bus publishes messages to every subscriber of a channel,
+// including the ones running in other Sonic replicas when backed by Redis
+type Bus interface {
+	Publish(channel, payload string) error
+	Subscribe(channel string, handler Handler) (unsubscribe func(), err error)
+	Close() error
+}
+
+// Connect returns a Redis backed bus, or an in-process one when Redis is not reachable
+func Connect(host, port string) Bus {
+	bus, err := NewRedisBus(fmt.Sprintf("%s:%s", host, port))
+	if err != nil {
+		log.Printf("WARN: Redis is not available, falling back to in-process events: %s", err.Error())
+		return NewLocalBus()
+	}
+	return bus
+}
+
+// LocalBus dispatches messages to subscribers of the current process only
+type LocalBus struct {
+	mutex    sync.RWMutex
+	handlers map[string]map[int]Handler
+	nextID   int
+}
+
+// NewLocalBus creates an in-process bus
+func NewLocalBus() *LocalBus {
+	return &LocalBus{
+		handlers: make(map[string]map[int]Handler),
+	}
+}
+
+// Publish calls every handler subscribed to the channel
+func (b *LocalBus) Publish(channel, payload string) error {
+	b.mutex.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[channel]))
+	for _, h := range b.handlers[channel] {
+		handlers = append(handlers, h)
+	}
+	b.mutex.RUnlock()
+
+	for _, h := range handlers {
+		go h(payload)
+	}
+	return nil
+}
+
+// Subscribe registers a handler for the channel
+func (b *LocalBus) Subscribe(channel string, handler Handler) (func(), error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.handlers[channel] == nil {
+		b.handlers[channel] = make(map[int]Handler)
+	}
+	id := b.nextID
+	b.nextID++
+	b.handlers[channel][id] = handler
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		delete(b.handlers[channel], id)
+	}, nil
+}
+
+// Close removes all the subscriptions
+func (b *LocalBus) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.handlers = make(map[string]map[int]Handler)
+	return nil
+}
+
+// RedisBus relies on Redis pub/sub to deliver messages to every replica
+type RedisBus struct {
+	client *redis.Client
+}
+
+// NewRedisBus connects to Redis and checks the connection
+func NewRedisBus(addr string) (*RedisBus, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        addr,
+		DialTimeout: 2 * time.Second,
+	})
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisBus{client: client}, nil
+}
+
+// Publish sends the payload to the Redis channel
+func (b *RedisBus) Publish(channel, payload string) error {
+	return b.client.Publish(channel, payload).Err()
+}
+
+// Subscribe listens to the Redis channel in a goroutine until unsubscribed,
+// the connection is re-established by go-redis on failures
+func (b *RedisBus) Subscribe(channel string, handler Handler) (func(), error) {
+	pubsub := b.client.Subscribe(channel)
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			handler(msg.Payload)
+		}
+	}()
+
+	return func() {
+		pubsub.Close()
+	}, nil
+}
+
+// Close closes the Redis connection
+func (b *RedisBus) Close() error {
+	return b.client.Close()
+}
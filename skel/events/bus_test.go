@@ -0,0 +1,117 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBus(t *testing.T) {
+	bus := NewLocalBus()
+	received := make(chan string, 2)
+
+	unsubscribe, err := bus.Subscribe("test", func(payload string) {
+		received <- payload
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish("test", "hello"))
+	require.NoError(t, bus.Publish("other", "ignored"))
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, "hello", payload)
+	case <-time.After(time.Second):
+		t.Fatal("message was not delivered")
+	}
+
+	unsubscribe()
+	require.NoError(t, bus.Publish("test", "after unsubscribe"))
+
+	select {
+	case payload := <-received:
+		t.Fatalf("unexpected message %q", payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConfigInvalidation(t *testing.T) {
+	bus := NewLocalBus()
+	received := make(chan ConfigInvalidation, 1)
+
+	_, err := SubscribeConfigInvalidation(bus, func(msg ConfigInvalidation) {
+		received <- msg
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, PublishConfigInvalidation(bus, "global", "public"))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, ConfigInvalidation{Component: "global", Scope: "public"}, msg)
+	case <-time.After(time.Second):
+		t.Fatal("invalidation was not delivered")
+	}
+}
+
+func TestConnectFallback(t *testing.T) {
+	bus := Connect("127.0.0.1", "1")
+	defer bus.Close()
+
+	_, ok := bus.(*LocalBus)
+	assert.True(t, ok)
+}
+
+func TestRetryBus(t *testing.T) {
+	retryMinDelay, retryMaxDelay = time.Millisecond, 5*time.Millisecond
+	defer func() {
+		retryMinDelay, retryMaxDelay = time.Second, time.Minute
+	}()
+
+	remote := NewLocalBus()
+	dialed := make(chan struct{})
+	attempts := 0
+	bus := newRetryBus(func() (Bus, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection refused")
+		}
+		close(dialed)
+		return remote, nil
+	})
+	defer bus.Close()
+	retry, ok := bus.(*RetryBus)
+	require.True(t, ok)
+
+	received := make(chan string, 1)
+	_, err := bus.Subscribe("test", func(payload string) {
+		received <- payload
+	})
+	require.NoError(t, err)
+	require.NoError(t, bus.Publish("test", "in-process"))
+	assert.Equal(t, "in-process", <-received)
+
+	select {
+	case <-dialed:
+	case <-time.After(time.Second):
+		t.Fatal("bus did not reconnect")
+	}
+	require.Eventually(t, func() bool {
+		retry.mutex.RLock()
+		defer retry.mutex.RUnlock()
+		return retry.remote != nil
+	}, time.Second, time.Millisecond)
+
+	// Subscriptions moved to the remote bus, which gets the messages of the other replicas
+	require.NoError(t, remote.Publish("test", "remote"))
+	assert.Equal(t, "remote", <-received)
+	require.NoError(t, retry.local.Publish("test", "stale"))
+	select {
+	case payload := <-received:
+		t.Fatalf("unexpected message %q", payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
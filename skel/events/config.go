@@ -0,0 +1,39 @@
+package events
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// ConfigChannel is the channel announcing secret changes
+const ConfigChannel = "sonic:config:invalidate"
+
+// ConfigInvalidation is published when secrets of a component scope change
+type ConfigInvalidation struct {
+	Component string `json:"component"`
+	Scope     string `json:"scope"`
+}
+
+// PublishConfigInvalidation announces that the secrets of a component scope changed
+func PublishConfigInvalidation(bus Bus, component, scope string) error {
+	payload, err := json.Marshal(ConfigInvalidation{
+		Component: component,
+		Scope:     scope,
+	})
+	if err != nil {
+		return err
+	}
+	return bus.Publish(ConfigChannel, string(payload))
+}
+
+// SubscribeConfigInvalidation calls the handler for every secret change announced on the bus
+func SubscribeConfigInvalidation(bus Bus, handler func(ConfigInvalidation)) (func(), error) {
+	return bus.Subscribe(ConfigChannel, func(payload string) {
+		msg := ConfigInvalidation{}
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			log.Printf("ERR: SubscribeConfigInvalidation: invalid payload %q: %s", payload, err)
+			return
+		}
+		handler(msg)
+	})
+}
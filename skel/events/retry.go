@@ -0,0 +1,164 @@
+package events
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Delays between the connection attempts of RetryBus, doubled after every failure
+var (
+	retryMinDelay = time.Second
+	retryMaxDelay = time.Minute
+)
+
+// RetryBus dispatches messages in-process while Redis is not reachable and keeps trying to connect to it,
+// the subscriptions are moved to Redis once it's connected
+type RetryBus struct {
+	mutex         sync.RWMutex
+	local         *LocalBus
+	remote        Bus
+	subscriptions map[int]*retrySubscription
+	nextID        int
+	closed        chan struct{}
+	closeOnce     sync.Once
+}
+
+type retrySubscription struct {
+	channel     string
+	handler     Handler
+	unsubscribe func()
+}
+
+// ConnectRetry returns a Redis backed bus, or a RetryBus reconnecting to Redis in the background
+// when it's not reachable. Long running processes use it, commands use Connect.
+func ConnectRetry(host, port string) Bus {
+	addr := fmt.Sprintf("%s:%s", host, port)
+	return newRetryBus(func() (Bus, error) {
+		return NewRedisBus(addr)
+	})
+}
+
+func newRetryBus(dial func() (Bus, error)) Bus {
+	remote, err := dial()
+	if err == nil {
+		return remote
+	}
+	log.Printf("WARN: Redis is not available, dispatching events in-process until it is: %s", err.Error())
+	b := &RetryBus{
+		local:         NewLocalBus(),
+		subscriptions: make(map[int]*retrySubscription),
+		closed:        make(chan struct{}),
+	}
+	go b.reconnect(dial)
+	return b
+}
+
+// reconnect dials with an exponential backoff until connected or closed
+func (b *RetryBus) reconnect(dial func() (Bus, error)) {
+	delay := retryMinDelay
+	for {
+		select {
+		case <-b.closed:
+			return
+		case <-time.After(delay):
+		}
+
+		remote, err := dial()
+		if err == nil {
+			if err = b.connected(remote); err == nil {
+				log.Println("Connected to Redis, events are dispatched to every replica")
+				return
+			}
+			remote.Close()
+		}
+		if delay *= 2; delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+		log.Printf("WARN: Redis is still not available, retrying in %s: %s", delay, err.Error())
+	}
+}
+
+// connected moves the subscriptions to remote, they stay in-process if one of them fails
+func (b *RetryBus) connected(remote Bus) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	select {
+	case <-b.closed:
+		return fmt.Errorf("bus is closed")
+	default:
+	}
+
+	unsubscribes := make(map[int]func(), len(b.subscriptions))
+	for id, sub := range b.subscriptions {
+		unsubscribe, err := remote.Subscribe(sub.channel, sub.handler)
+		if err != nil {
+			for _, undo := range unsubscribes {
+				undo()
+			}
+			return err
+		}
+		unsubscribes[id] = unsubscribe
+	}
+	for id, sub := range b.subscriptions {
+		sub.unsubscribe()
+		sub.unsubscribe = unsubscribes[id]
+	}
+	b.remote = remote
+	return nil
+}
+
+// Publish sends the payload to Redis once connected, to the subscribers of the process before
+func (b *RetryBus) Publish(channel, payload string) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if b.remote != nil {
+		return b.remote.Publish(channel, payload)
+	}
+	return b.local.Publish(channel, payload)
+}
+
+// Subscribe registers a handler for the channel, it's kept when the bus connects to Redis
+func (b *RetryBus) Subscribe(channel string, handler Handler) (func(), error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var unsubscribe func()
+	var err error
+	if b.remote != nil {
+		unsubscribe, err = b.remote.Subscribe(channel, handler)
+	} else {
+		unsubscribe, err = b.local.Subscribe(channel, handler)
+	}
+	if err != nil {
+		return nil, err
+	}
+	id := b.nextID
+	b.nextID++
+	b.subscriptions[id] = &retrySubscription{channel: channel, handler: handler, unsubscribe: unsubscribe}
+
+	return func() {
+		b.mutex.Lock()
+		sub, ok := b.subscriptions[id]
+		delete(b.subscriptions, id)
+		b.mutex.Unlock()
+		if ok {
+			sub.unsubscribe()
+		}
+	}, nil
+}
+
+// Close stops reconnecting and closes the bus in use
+func (b *RetryBus) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+	})
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.local.Close()
+	if b.remote != nil {
+		return b.remote.Close()
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cookie holding the CSRF token, the page gets the same token in its boot data
+const csrfCookie = "csrf_token"
+
+// Header carrying the CSRF token on the requests changing data
+const csrfHeader = "X-CSRF-Token"
+
+// Length of the CSRF token in bytes
+const csrfTokenLength = 32
+
+// bootData is inlined in the index page, so the frontend starts without requesting the API
+type bootData struct {
+	Config json.RawMessage `json:"config"`
+	// ConfigHash is sent as Last-Event-ID by the config stream to skip the config already known
+	ConfigHash string `json:"configHash"`
+	Version    string `json:"version"`
+	CSRFToken  string `json:"csrfToken"`
+}
+
+// bootScript serializes the boot data for a script tag, encoding/json escapes <, > and &
+// so the content can't close the tag
+func bootScript(data bootData) (template.JS, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(raw), nil
+}
+
+// pageBoot returns the boot data of the request, a new CSRF token cookie is set if there is none
+func pageBoot(ctx *gin.Context) bootData {
+	body, hash, _ := publicConfig.get()
+	return bootData{
+		Config:     body,
+		ConfigHash: hash,
+		Version:    Version,
+		CSRFToken:  csrfToken(ctx),
+	}
+}
+
+// contentSecurityPolicy allows the scripts of the site and the inline ones carrying the nonce of the response
+func contentSecurityPolicy(ctx *gin.Context) (string, error) {
+	nonce, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	value := base64.RawURLEncoding.EncodeToString(nonce)
+	ctx.Header("Content-Security-Policy", fmt.Sprintf("script-src 'self' 'nonce-%s'; object-src 'none'; base-uri 'self'", value))
+	return value, nil
+}
+
+// csrfToken returns the token of the cookie, or sets a cookie with a new one
+func csrfToken(ctx *gin.Context) string {
+	if cookie, err := ctx.Cookie(csrfCookie); err == nil && validCSRFToken(cookie) {
+		return cookie
+	}
+
+	raw, err := randomBytes(csrfTokenLength)
+	if err != nil {
+		log.Printf("ERR: csrfToken: %s", err)
+		return ""
+	}
+	token := hex.EncodeToString(raw)
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   ctx.Request.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token
+}
+
+// CSRFMiddleware rejects the requests changing data without the X-CSRF-Token header matching the cookie,
+// it protects the routes authenticated by cookies
+func CSRFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		cookie, err := c.Cookie(csrfCookie)
+		header := c.GetHeader(csrfHeader)
+		if err != nil || !validCSRFToken(cookie) || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid CSRF token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func validCSRFToken(token string) bool {
+	raw, err := hex.DecodeString(token)
+	return err == nil && len(raw) == csrfTokenLength
+}
+
+func randomBytes(n int) ([]byte, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
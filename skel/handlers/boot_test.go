@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexBoot(t *testing.T) {
+	defer func(version string) { Version = version }(Version)
+	Version = "1.2.3"
+	_, err := publicConfig.set(map[string]interface{}{"title": "</script><script>alert(1)</script>"})
+	require.NoError(t, err)
+	defer publicConfig.set(map[string]interface{}{})
+
+	router := gin.New()
+	router.HTMLRender = newViewEngine(fstest.MapFS{
+		"views/layouts/master.html": {Data: []byte(`{{template "content" .}}`)},
+		"views/index.html": {Data: []byte(`{{define "content"}}` +
+			`<script id="boot" type="application/json" nonce="{{ .nonce }}">{{ .boot }}</script>{{end}}`)},
+	}, false)
+	router.GET("/", index)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	match := regexp.MustCompile(`<script id="boot" type="application/json" nonce="([^"]+)">(.*)</script>$`).FindStringSubmatch(w.Body.String())
+	require.Len(t, match, 3, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "script-src 'self' 'nonce-"+match[1]+"'")
+	assert.NotContains(t, match[2], "</script>")
+
+	boot := bootData{}
+	require.NoError(t, json.Unmarshal([]byte(match[2]), &boot))
+	assert.JSONEq(t, `{"title":"</script><script>alert(1)</script>"}`, string(boot.Config))
+	_, hash, _ := publicConfig.get()
+	assert.Equal(t, hash, boot.ConfigHash)
+	assert.Equal(t, "1.2.3", boot.Version)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, csrfCookie, cookies[0].Name)
+	assert.Equal(t, boot.CSRFToken, cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+
+	// The token is kept while the cookie is valid, the nonce changes on every response
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookies[0])
+	w2 := httptest.NewRecorder()
+	router.ServeHTTP(w2, r)
+	assert.Empty(t, w2.Result().Cookies())
+	assert.Contains(t, w2.Body.String(), boot.CSRFToken)
+	assert.NotEqual(t, w.Header().Get("Content-Security-Policy"), w2.Header().Get("Content-Security-Policy"))
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	token := strings.Repeat("ab", csrfTokenLength)
+	router := gin.New()
+	router.Use(CSRFMiddleware())
+	router.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	router.POST("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	request := func(method, cookie, header string) int {
+		r := httptest.NewRequest(method, "/", nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: csrfCookie, Value: cookie})
+		}
+		if header != "" {
+			r.Header.Set(csrfHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "", ""))
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, token, token))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, token, ""))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "", token))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, token, strings.Repeat("cd", csrfTokenLength)))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "short", "short"))
+}
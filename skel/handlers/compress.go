@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/assets"
+)
+
+// Content types compressed by GzipMiddleware
+var gzipTypes = []string{"text/html", "application/json"}
+
+// gzipETagSuffix is added to the strong entity tags of the responses compressed by GzipMiddleware,
+// since the compressed bytes are another representation
+const gzipETagSuffix = "-gzip"
+
+// PrecompressedMiddleware serves the .br or .gz sibling of a static file written by 'asset build'
+// when the client accepts its encoding, the other requests go on to the static handler.
+// prefix is the path the files are served at.
+func PrecompressedMiddleware(files http.FileSystem, prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		name := strings.TrimPrefix(c.Request.URL.Path, prefix)
+		accepted := c.GetHeader("Accept-Encoding")
+
+		for _, encoding := range assets.Encodings {
+			file, err := files.Open(name + encoding.Suffix)
+			if err != nil {
+				continue
+			}
+			// The response depends on the header as soon as a sibling exists
+			c.Writer.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsEncoding(accepted, encoding.Name) {
+				file.Close()
+				continue
+			}
+
+			info, err := file.Stat()
+			if err != nil {
+				file.Close()
+				continue
+			}
+			c.Header("Content-Encoding", encoding.Name)
+			if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+				c.Header("Content-Type", contentType)
+			}
+			http.ServeContent(c.Writer, c.Request, name, info.ModTime(), file)
+			file.Close()
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GzipMiddleware compresses HTML and JSON responses of at least minSize bytes
+// for clients accepting gzip, smaller responses and range requests are sent as is
+func GzipMiddleware(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsEncoding(c.GetHeader("Accept-Encoding"), "gzip") || c.GetHeader("Range") != "" {
+			c.Next()
+			return
+		}
+		w := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize, status: http.StatusOK, ifNoneMatch: c.GetHeader("If-None-Match")}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// gzipWriter holds the beginning of the response until it knows if it's worth compressing
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize     int
+	status      int
+	ifNoneMatch string
+	buffer      []byte
+	decided     bool
+	gz          *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *gzipWriter) Status() int {
+	if w.decided {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if !w.decided && !compressibleType(w.Header().Get("Content-Type")) {
+		w.decide()
+	}
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	if w.decided {
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buffer = append(w.buffer, data...)
+	if len(w.buffer) >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipWriter) WriteHeaderNow() {
+	w.decide()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush sends the buffered response, streams are compressed from their first flush if they are large enough
+func (w *gzipWriter) Flush() {
+	w.decide()
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide writes the headers and the buffered data, compressed if the response is large enough
+func (w *gzipWriter) decide() error {
+	if w.decided {
+		return nil
+	}
+	w.decided = true
+
+	header := w.Header()
+	if len(w.buffer) >= w.minSize && header.Get("Content-Encoding") == "" && compressibleType(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	// Not modified responses keep the tag of the representation the client has
+	if tag := header.Get("ETag"); w.gz != nil || (w.status == http.StatusNotModified && strings.Contains(w.ifNoneMatch, gzipETag(tag))) {
+		header.Set("ETag", gzipETag(tag))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+	if w.gz != nil {
+		_, err := w.gz.Write(buffer)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffer)
+	return err
+}
+
+func (w *gzipWriter) finish() {
+	w.decide()
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
+
+// gzipETag returns the tag of the compressed representation, weak and missing tags are kept
+func gzipETag(tag string) string {
+	if !strings.HasPrefix(tag, `"`) || strings.HasSuffix(tag, gzipETagSuffix+`"`) {
+		return tag
+	}
+	return strings.TrimSuffix(tag, `"`) + gzipETagSuffix + `"`
+}
+
+func compressibleType(contentType string) bool {
+	for _, t := range gzipTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsEncoding tells if the Accept-Encoding header allows encoding, f.e. "gzip, deflate, br;q=0.5"
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		name := strings.TrimSpace(params[0])
+		if name != encoding && name != "*" {
+			continue
+		}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
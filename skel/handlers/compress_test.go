@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrecompressedMiddleware(t *testing.T) {
+	public, err := publicFiles(fstest.MapFS{
+		"public/main.js":    {Data: []byte("console.log(1)")},
+		"public/main.js.br": {Data: []byte("brotli")},
+		"public/main.js.gz": {Data: []byte("gzip")},
+		"public/logo.svg":   {Data: []byte("<svg/>")},
+	})
+	require.NoError(t, err)
+	router := gin.New()
+	router.Group("/public", PrecompressedMiddleware(public, "/public")).StaticFS("/", public)
+
+	get := func(path, encoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept-Encoding", encoding)
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/public/main.js", "gzip, deflate, br")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "brotli", w.Body.String())
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+	w = get("/public/main.js", "gzip, br;q=0")
+	assert.Equal(t, "gzip", w.Body.String())
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	w = get("/public/main.js", "")
+	assert.Equal(t, "console.log(1)", w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+	w = get("/public/logo.svg", "br")
+	assert.Equal(t, "<svg/>", w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Vary"))
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	large := strings.Repeat("sonic ", 100)
+	router := gin.New()
+	router.Use(GzipMiddleware(100))
+	router.GET("/large", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusCreated, gin.H{"data": large})
+	})
+	router.GET("/small", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"data": "sonic"})
+	})
+	router.GET("/text", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, large)
+	})
+	router.GET("/tagged", func(ctx *gin.Context) {
+		ctx.Header("ETag", `"v1"`)
+		if etagMatch(ctx.GetHeader("If-None-Match"), `"v1"`) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"data": large})
+	})
+
+	revalidate := func(path, encoding, tag string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept-Encoding", encoding)
+		if tag != "" {
+			r.Header.Set("If-None-Match", tag)
+		}
+		router.ServeHTTP(w, r)
+		return w
+	}
+	get := func(path, encoding string) *httptest.ResponseRecorder {
+		return revalidate(path, encoding, "")
+	}
+
+	w := get("/large", "gzip")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	gz, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	raw, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":"`+large+`"}`, string(raw))
+
+	w = get("/large", "br")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"data":"`+large+`"}`, w.Body.String())
+
+	w = get("/small", "gzip")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"data":"sonic"}`, w.Body.String())
+
+	w = get("/text", "gzip")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, w.Body.String())
+
+	// The compressed representation has its own entity tag
+	w = get("/tagged", "gzip")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"v1-gzip"`, w.Header().Get("ETag"))
+	w = get("/tagged", "identity")
+	assert.Equal(t, `"v1"`, w.Header().Get("ETag"))
+	w = revalidate("/tagged", "gzip", `"v1-gzip"`)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, `"v1-gzip"`, w.Header().Get("ETag"))
+	w = revalidate("/tagged", "gzip", `"v1"`)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, `"v1"`, w.Header().Get("ETag"))
+	w = revalidate("/tagged", "gzip", `"v0-gzip"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
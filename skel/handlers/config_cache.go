@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// configCache holds the serialized public config with its content hash.
+// Waiters are notified of a change by closing the changed channel.
+type configCache struct {
+	mutex   sync.RWMutex
+	data    map[string]interface{}
+	body    []byte
+	hash    string
+	changed chan struct{}
+}
+
+func newConfigCache() *configCache {
+	c := &configCache{changed: make(chan struct{})}
+	c.set(make(map[string]interface{}))
+	return c
+}
+
+// set replaces the cached config and reports whether its content changed
+func (c *configCache) set(data map[string]interface{}) (bool, error) {
+	// Map keys are sorted by encoding/json, so equal configs give equal hashes
+	body, err := json.Marshal(data)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:16])
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if hash == c.hash {
+		return false, nil
+	}
+	c.data = data
+	c.body = body
+	c.hash = hash
+	close(c.changed)
+	c.changed = make(chan struct{})
+	return true, nil
+}
+
+// get returns the serialized config, its hash and a channel closed on the next change
+func (c *configCache) get() ([]byte, string, <-chan struct{}) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.body, c.hash, c.changed
+}
+
+// etag returns the strong entity tag of a content hash
+func etag(hash string) string {
+	return `"` + hash + `"`
+}
+
+// etagMatch checks an If-None-Match header against the current entity tag,
+// the tags of the representations compressed by GzipMiddleware match it too
+func etagMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag || candidate == gzipETag(tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openware/sonic/skel/content"
+)
+
+// Translator returns the text of key in lang, args fill the placeholders of the text
+type Translator func(lang, key string, args ...interface{}) string
+
+// translator is used by the t template function, texts are their key until catalogs are set
+var translator Translator = func(lang, key string, args ...interface{}) string {
+	return key
+}
+
+// SetTranslator replaces the lookup of the t template function
+func SetTranslator(t Translator) {
+	translator = t
+}
+
+// templateFuncs are available in every view, values come last so they can be piped,
+// f.e. {{ .CreatedAt | date "Jan 2, 2006" }}
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"add":      add,
+		"markdown": content.Markdown,
+		"date":     formatDate,
+		"number":   formatNumber,
+		"t":        translate,
+		"asset":    assetURL,
+		"truncate": truncate,
+		"safeHTML": safeHTML,
+		"json":     safeJSON,
+		"url":      routeURL,
+		"menu":     menuLinks,
+	}
+}
+
+func add(a, b int) int {
+	return a + b
+}
+
+// translate looks up key in the catalog of lang, {{ t .lang "home.title" }}
+func translate(lang, key string, args ...interface{}) string {
+	return translator(lang, key, args...)
+}
+
+// assetURL returns the URL of a frontend file from the asset manifest, {{ asset "main.js" }}
+func assetURL(name string) string {
+	return bundle.Path(name)
+}
+
+// formatDate formats times, RFC 3339 strings and unix timestamps with a Go layout, nil times give an empty string
+func formatDate(layout string, value interface{}) (string, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		t = *v
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return "", err
+		}
+		t = parsed
+	case int:
+		t = time.Unix(int64(v), 0).UTC()
+	case int64:
+		t = time.Unix(v, 0).UTC()
+	default:
+		return "", fmt.Errorf("date: unsupported value %T", value)
+	}
+	return t.Format(layout), nil
+}
+
+// formatNumber formats a number with the given decimals and thousands separators, 1234.5 gives "1,234.50"
+func formatNumber(decimals int, value interface{}) (string, error) {
+	var n float64
+	switch v := value.(type) {
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case uint:
+		n = float64(v)
+	case uint64:
+		n = float64(v)
+	case float32:
+		n = float64(v)
+	case float64:
+		n = v
+	case string:
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "", err
+		}
+		n = parsed
+	default:
+		return "", fmt.Errorf("number: unsupported value %T", value)
+	}
+
+	raw := strconv.FormatFloat(n, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(raw, "-") {
+		sign, raw = "-", raw[1:]
+	}
+	integer, fraction := raw, ""
+	if i := strings.Index(raw, "."); i >= 0 {
+		integer, fraction = raw[:i], raw[i:]
+	}
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + fraction, nil
+}
+
+// truncate shortens s to n characters, an ellipsis ends the shortened texts
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+// safeHTML inserts trusted HTML without escaping, it must never get user input
+func safeHTML(s string) template.HTML {
+	return template.HTML(s)
+}
+
+// safeJSON serializes a value for a script, encoding/json escapes <, > and & so it can't close the tag
+func safeJSON(value interface{}) (template.JS, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(raw), nil
+}
+
+// routeURL builds the URL of a route from its pattern and name, value pairs.
+// Values fill the :name and *name segments, the other pairs are added to the query,
+// f.e. {{ url "/pages/:path" "path" "faq" "lang" "en" }} gives /pages/faq?lang=en
+func routeURL(pattern string, pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("url %s: parameters must be name, value pairs", pattern)
+	}
+	params := map[string]string{}
+	for i := 0; i < len(pairs); i += 2 {
+		params[fmt.Sprint(pairs[i])] = fmt.Sprint(pairs[i+1])
+	}
+
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		name := segment[1:]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("url %s: missing parameter %s", pattern, name)
+		}
+		delete(params, name)
+		if segment[0] == '*' {
+			// Catch-all parameters keep their slashes
+			parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+			for j := range parts {
+				parts[j] = url.PathEscape(parts[j])
+			}
+			segments[i] = strings.Join(parts, "/")
+			continue
+		}
+		segments[i] = url.PathEscape(value)
+	}
+	path := strings.Join(segments, "/")
+
+	if len(params) == 0 {
+		return path, nil
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	query := url.Values{}
+	for _, name := range names {
+		query.Set(name, params[name])
+	}
+	return path + "?" + query.Encode(), nil
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/openware/sonic/skel/assets"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, text string, data interface{}) string {
+	tpl, err := template.New("test").Funcs(templateFuncs()).Parse(text)
+	require.NoError(t, err)
+	out := &bytes.Buffer{}
+	require.NoError(t, tpl.Execute(out, data))
+	return out.String()
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	created := time.Date(2021, 6, 15, 12, 30, 0, 0, time.UTC)
+	data := map[string]interface{}{
+		"created": created,
+		"body":    "# Title\n\n<script>alert(1)</script>[link](javascript:alert(1))",
+		"config":  map[string]string{"title": "</script>"},
+	}
+
+	assert.Equal(t, "184", render(t, `{{ add 42 142 }}`, nil))
+	assert.Equal(t, "Jun 15, 2021", render(t, `{{ .created | date "Jan 2, 2006" }}`, data))
+	assert.Equal(t, "2021-06-15", render(t, `{{ date "2006-01-02" "2021-06-15T12:30:00Z" }}`, nil))
+	assert.Equal(t, "1,234,567.89", render(t, `{{ 1234567.891 | number 2 }}`, nil))
+	assert.Equal(t, "-1,000", render(t, `{{ number 0 -1000 }}`, nil))
+	assert.Equal(t, "123", render(t, `{{ number 0 "123" }}`, nil))
+	assert.Equal(t, "Hello…", render(t, `{{ "Hello world" | truncate 6 }}`, nil))
+	assert.Equal(t, "Hello", render(t, `{{ "Hello" | truncate 6 }}`, nil))
+	assert.Equal(t, "<b>bold</b>", render(t, `{{ safeHTML "<b>bold</b>" }}`, nil))
+	assert.Equal(t, "&lt;b&gt;", render(t, `{{ "<b>" }}`, nil))
+	assert.Equal(t, `<script>{"title":"\u003c/script\u003e"}</script>`, render(t, `<script>{{ json .config }}</script>`, data))
+	assert.Equal(t, "home.title", render(t, `{{ t "en" "home.title" }}`, nil))
+
+	html := render(t, `{{ markdown .body }}`, data)
+	assert.Contains(t, html, "<h1")
+	assert.NotContains(t, html, "<script>")
+	assert.NotContains(t, html, "javascript:")
+}
+
+func TestTranslator(t *testing.T) {
+	defer SetTranslator(translator)
+	SetTranslator(func(lang, key string, args ...interface{}) string {
+		return lang + ":" + key
+	})
+	assert.Equal(t, "fr:home.title", render(t, `{{ t "fr" "home.title" }}`, nil))
+}
+
+func TestAssetURL(t *testing.T) {
+	defer func(b *assets.Bundle) { bundle = b }(bundle)
+	bundle = loadBundle(fstest.MapFS{
+		"public/assets/manifest.json": {Data: []byte(`{"main.js": {"file": "main.0a286891.js", "isEntry": true}}`)},
+	}, settings.AssetConfig{Output: "public/assets"})
+	assert.Equal(t, "/public/assets/main.0a286891.js", render(t, `{{ asset "main.js" }}`, nil))
+	assert.Equal(t, "/public/assets/logo.svg", render(t, `{{ asset "logo.svg" }}`, nil))
+}
+
+func TestRouteURL(t *testing.T) {
+	url, err := routeURL("/pages/:path", "path", "faq", "lang", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "/pages/faq?lang=en", url)
+
+	url, err = routeURL("/docs/*path", "path", "/guide/start here")
+	require.NoError(t, err)
+	assert.Equal(t, "/docs/guide/start%20here", url)
+
+	_, err = routeURL("/pages/:path")
+	assert.EqualError(t, err, "url /pages/:path: missing parameter path")
+	_, err = routeURL("/pages/:path", "path")
+	assert.Error(t, err)
+
+	assert.Equal(t, "/pages/a%2Fb", render(t, `{{ url "/pages/:path" "path" "a/b" }}`, nil))
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/i18n"
+	"github.com/openware/sonic/skel/settings"
+)
+
+// LocalesDir holds the message catalogs, one file by locale
+const LocalesDir = "config/locales"
+
+// Message catalogs of the views and the frontend, loaded once by Setup
+var catalogs = i18n.New("", nil)
+
+// loadCatalogs reads the catalogs of LocalesDir and makes them the translations of the t template function,
+// texts stay their key when they can't be read
+func loadCatalogs(fsys fs.FS, cnf settings.LanguagesConfig) *i18n.Catalogs {
+	c, err := i18n.Load(fsys, LocalesDir, cnf.Default)
+	if err != nil {
+		log.Printf("ERR: loadCatalogs: %s", err)
+		c = i18n.New(cnf.Default, nil)
+	}
+	SetTranslator(c.Translate)
+	return c
+}
+
+// LanguageMiddleware sets the languages of the request to gin context, by preference
+func LanguageMiddleware(reloader *settings.Reloader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("Languages", requestLanguages(c, reloader.Current().Languages, ""))
+		c.Next()
+	}
+}
+
+// requestLang returns the preferred language of the request, empty without LanguageMiddleware
+func requestLang(ctx *gin.Context) string {
+	if langs, ok := ctx.Value("Languages").([]string); ok && len(langs) > 0 {
+		return langs[0]
+	}
+	return ""
+}
+
+// GetCatalog handles GET '/api/v2/public/i18n/:lang'
+// It returns the messages of the locale, completed by its base language and the default locale.
+// Plural messages are objects with a text by plural category.
+func GetCatalog(ctx *gin.Context) {
+	lang := ctx.Param("lang")
+	if !catalogs.Has(lang) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "locale not found"})
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, catalogs.Messages(lang))
+}
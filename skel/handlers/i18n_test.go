@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogAPI(t *testing.T) {
+	defer SetTranslator(translator)
+	previous := catalogs
+	defer func() { catalogs = previous }()
+	catalogs = loadCatalogs(fstest.MapFS{
+		"config/locales/en.yml": {Data: []byte("index:\n  title: Home\n  heading: Welcome\n")},
+		"config/locales/ru.yml": {Data: []byte("index:\n  title: Главная\n")},
+	}, settings.LanguagesConfig{Default: "en"})
+
+	reloader := settings.NewReloader(&settings.Config{
+		Languages: settings.LanguagesConfig{Default: "en", Supported: []string{"en", "ru"}},
+	}, nil, nil)
+	router := gin.New()
+	router.HTMLRender = newViewEngine(fstest.MapFS{
+		"views/page.html": {Data: []byte(`{{ .lang }}: {{ t .lang "index.title" }}, {{ t .lang "index.heading" }}`)},
+	}, false)
+	router.GET("/page", LanguageMiddleware(reloader), emptyPage)
+	router.GET("/api/v2/public/i18n/:lang", GetCatalog)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/page", nil)
+	r.Header.Set("Accept-Language", "ru-RU,ru;q=0.9")
+	router.ServeHTTP(w, r)
+	assert.Equal(t, "ru: Главная, Welcome", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/public/i18n/ru", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"index.title": "Главная", "index.heading": "Welcome"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/public/i18n/de", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
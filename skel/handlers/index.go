@@ -1,20 +1,23 @@
 package handlers
 
 import (
-	"github.com/foolin/goview/supports/ginview"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/openware/kaigara/pkg/vault"
 	"github.com/openware/pkg/mngapi/peatio"
 	"github.com/openware/pkg/sonic/config"
 	"github.com/openware/pkg/sonic/handlers"
 	"github.com/openware/pkg/utils"
+	"github.com/openware/sonic/skel/assets"
 	"github.com/openware/sonic/skel/daemons"
+	"github.com/openware/sonic/skel/events"
 	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/secrets"
+	"github.com/openware/sonic/skel/settings"
+	"io/fs"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
-	"regexp"
+	"path"
 	"strings"
 )
 
@@ -29,19 +32,19 @@ type SonicContext struct {
 	PeatioClient *peatio.Client
 }
 
-// Initialize scope which goroutine will fetch every 30 seconds
+// Scope of the global component served by the public config endpoint
 const scope = "public"
 
 // Setup set up routes to render view HTML
-func Setup(app *config.Runtime) {
+func Setup(app *config.Runtime, reloader *settings.Reloader, fsys fs.FS) {
 	// Get config and env
 	Version = app.Version
 	DeploymentID = app.Conf.DeploymentID
 	handlers.SonicPublicKey = utils.GetEnv("SONIC_PUBLIC_KEY", "")
 	handlers.PeatioPublicKey = utils.GetEnv("PEATIO_PUBLIC_KEY", "")
 	handlers.BarongPublicKey = utils.GetEnv("BARONG_PUBLIC_KEY", "")
+	cnf := reloader.Current()
 	vaultConfig := app.Conf.Vault
-	opendaxConfig := app.Conf.Opendax
 	mngapiConfig := app.Conf.MngAPI
 
 	peatioClient, err := peatio.New(mngapiConfig.PeatioURL, mngapiConfig.JWTIssuer, mngapiConfig.JWTAlgo, mngapiConfig.JWTPrivateKey)
@@ -55,34 +58,101 @@ func Setup(app *config.Runtime) {
 	// Get app router
 	router := app.Srv
 
-	// Set up view engine
-	router.HTMLRender = ginview.Default()
+	// Compress the pages and the API responses
+	router.Use(GzipMiddleware(assets.MinCompressSize))
 
-	// Serve static files
-	router.Static("/public", "./public")
+	// Set up view engine, templates are reloaded on change when read from the disk
+	router.HTMLRender = newViewEngine(fsys, !cnf.Embed)
 
-	router.GET("/", index)
-	router.GET("/page", emptyPage)
+	// Serve static files, the asset manifest is read once
+	bundle = loadBundle(fsys, cnf.Asset)
+	public, err := publicFiles(fsys)
+	if err != nil {
+		log.Printf("Can't serve public files: " + err.Error())
+		return
+	}
+	// Translate the views with the catalogs of config/locales
+	catalogs = loadCatalogs(fsys, cnf.Languages)
+
+	static := router.Group(publicPrefix, StaticCacheMiddleware(bundle), PrecompressedMiddleware(public, publicPrefix))
+	static.StaticFS("/", public)
+
+	// Serve the uploads of the media library
+	if storage, err := newMediaStorage(cnf.Media); err != nil {
+		log.Printf("ERR: Setup: %s", err)
+	} else {
+		mediaStorage = storage
+	}
+	serveMedia(router, mediaStorage)
+
+	router.GET("/", LanguageMiddleware(reloader), index)
+	router.GET("/page", LanguageMiddleware(reloader), emptyPage)
 	router.GET("/version", version)
+	router.GET("/sitemap.xml", SettingsMiddleware(reloader), Sitemap)
+	router.GET("/robots.txt", SettingsMiddleware(reloader), robots(fsys))
+	router.GET(previewPrefix+"/:token", SettingsMiddleware(reloader), LanguageMiddleware(reloader), PreviewPage)
 
-	router.NoRoute(notFound)
+	router.NoRoute(SettingsMiddleware(reloader), notFound(reloader, models.FindPageByPath, models.FindRedirect))
 
-	handlers.SetPageRoutes(router, &models.Page{})
 
 	// Initialize Vault Service
 	vaultService := vault.NewService(vaultConfig.Addr, vaultConfig.Token, DeploymentID)
 
+	// Secret changes are announced to every replica through Redis
+	bus := events.ConnectRetry(app.Conf.Redis.Host, app.Conf.Redis.Port)
+
+	// Initialize secret versions store
+	secretStore, err := secrets.NewStore(vaultConfig.Addr, vaultConfig.Token, DeploymentID)
+	if err != nil {
+		log.Printf("Can't create secret store: " + err.Error())
+		return
+	}
+
 	adminAPI := router.Group("/api/v2/admin")
 	adminAPI.Use(handlers.VaultServiceMiddleware(vaultService))
-	adminAPI.Use(handlers.OpendaxConfigMiddleware(&opendaxConfig))
+	adminAPI.Use(SecretStoreMiddleware(secretStore))
+	adminAPI.Use(EventBusMiddleware(bus))
+	adminAPI.Use(OpendaxConfigMiddleware(reloader))
+	adminAPI.Use(SettingsMiddleware(reloader))
+	adminAPI.Use(RouterMiddleware(router))
 	adminAPI.Use(handlers.AuthMiddleware())
 	adminAPI.Use(handlers.RBACMiddleware([]string{"superadmin"}))
 	adminAPI.Use(handlers.SonicContextMiddleware(&handlers.SonicContext{
 		PeatioClient: peatioClient,
 	}))
 
-	adminAPI.GET("/secrets", handlers.GetSecrets)
-	adminAPI.PUT(":component/secret", handlers.SetSecret)
+	adminAPI.GET("/secrets", withVaultLock(handlers.GetSecrets))
+	adminAPI.PUT(":component/secret", SetSecret)
+	adminAPI.GET(":component/secret/versions", GetSecretVersions)
+	adminAPI.GET(":component/secret/diff", GetSecretDiff)
+	adminAPI.POST(":component/secret/rollback", RollbackSecret)
+	adminAPI.GET("/pages", ListPages)
+	adminAPI.GET("/pages/:id", GetPage)
+	adminAPI.POST("/pages", CreatePage)
+	adminAPI.PUT("/pages/:id", UpdatePage)
+	adminAPI.DELETE("/pages/:id", DeletePage)
+	adminAPI.POST("/pages/:id/preview", CreatePagePreview)
+	adminAPI.GET("/pages/:id/revisions", ListPageRevisions)
+	adminAPI.GET("/pages/:id/revisions/:version", GetPageRevision)
+	adminAPI.POST("/pages/:id/revisions/:version/restore", RestorePageRevision)
+	adminAPI.GET("/pages/:id/diff", GetPageDiff)
+
+	adminAPI.GET("/menus", ListMenus)
+	adminAPI.GET("/menus/:id", GetMenu)
+	adminAPI.POST("/menus", CreateMenu)
+	adminAPI.PUT("/menus/:id", UpdateMenu)
+	adminAPI.DELETE("/menus/:id", DeleteMenu)
+
+	adminAPI.GET("/redirects", ListRedirects)
+	adminAPI.GET("/redirects/:id", GetRedirect)
+	adminAPI.POST("/redirects", CreateRedirect)
+	adminAPI.PUT("/redirects/:id", UpdateRedirect)
+	adminAPI.DELETE("/redirects/:id", DeleteRedirect)
+
+	adminAPI.GET("/media", ListMedia)
+	adminAPI.GET("/media/:id", GetMedia)
+	adminAPI.POST("/media", UploadMedia)
+	adminAPI.DELETE("/media/:id", DeleteMedia)
 	adminAPI.POST("/platforms/new", func(ctx *gin.Context) {
 		handlers.CreatePlatform(ctx, daemons.CreateNewLicense, daemons.FetchConfiguration)
 		return
@@ -91,14 +161,28 @@ func Setup(app *config.Runtime) {
 	publicAPI := router.Group("/api/v2/public")
 	publicAPI.Use(handlers.VaultServiceMiddleware(vaultService))
 
-	publicAPI.GET("/config", handlers.GetPublicConfigs)
+	publicAPI.GET("/config", GetPublicConfigs)
+	publicAPI.GET("/config/stream", StreamPublicConfigs)
+	publicAPI.GET("/i18n/:lang", GetCatalog)
+	publicAPI.GET("/pages/search", SearchPages)
+	publicAPI.GET("/menus/:name", GetPublicMenu)
 
 	// Define all public env on first system start
-	handlers.WriteCache(vaultService, scope, true)
-	go handlers.StartConfigCaching(vaultService, scope)
+	if err := RefreshPublicConfig(vaultService); err != nil {
+		log.Printf("Can't load public config: " + err.Error())
+	}
+	go StartConfigInvalidation(bus, vaultService)
+
+	if !cnf.Daemons.Enabled {
+		log.Println("Daemons are disabled in the", cnf.Env, "environment")
+		return
+	}
+
+	// Publish and archive the scheduled pages
+	go daemons.PublishScheduledPages(reloader)
 
 	// Run LicenseRenewal
-	go daemons.LicenseRenewal("finex", app, vaultService)
+	go daemons.LicenseRenewal("finex", reloader, vaultService)
 
 	// Fetch currencies and markets from the main platform periodically
 	enabled, err := daemons.GetXLNEnabledFromVault(vaultService)
@@ -106,37 +190,78 @@ func Setup(app *config.Runtime) {
 		log.Printf("cannot determine whether XLN is enabled: " + err.Error())
 	}
 	if enabled {
-		go daemons.FetchConfigurationPeriodic(peatioClient, vaultService, opendaxConfig.Addr)
+		go daemons.FetchConfigurationPeriodic(peatioClient, vaultService, reloader)
 	}
 }
 
+// SettingsMiddleware sets the current configuration to gin context
+func SettingsMiddleware(reloader *settings.Reloader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("Settings", reloader.Current())
+		c.Next()
+	}
+}
 
-// index render with master layer
+// RouterMiddleware sets the router to gin context, so handlers can tell which paths are routes
+func RouterMiddleware(router *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("Router", router)
+		c.Next()
+	}
+}
+
+// GetSettings helper return the configuration from gin context
+func GetSettings(ctx *gin.Context) (*settings.Config, error) {
+	cnf, ok := ctx.MustGet("Settings").(*settings.Config)
+	if !ok {
+		return nil, fmt.Errorf("Settings are not found")
+	}
+	return cnf, nil
+}
+
+// OpendaxConfigMiddleware sets the Opendax config of the current configuration to gin context,
+// so a reload applies to the next requests
+func OpendaxConfigMiddleware(reloader *settings.Reloader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("OpendaxConfig", &reloader.Current().Opendax)
+		c.Next()
+	}
+}
+
+// index render with master layer, the boot data of the frontend is inlined in the page
 func index(ctx *gin.Context) {
-	cssFiles, err := FilesPaths("/public/assets/*.css")
+	nonce, err := contentSecurityPolicy(ctx)
 	if err != nil {
-		log.Println("filePaths:", "Can't take list of paths for css files: "+err.Error())
+		log.Printf("ERR: index: %s", err)
+		ctx.Status(http.StatusInternalServerError)
+		return
 	}
-
-	jsFiles, err := FilesPaths("/public/assets/*.js")
+	boot, err := bootScript(pageBoot(ctx))
 	if err != nil {
-		log.Println("filePaths", "Can't take list of paths for js files in public folder: "+err.Error())
+		log.Printf("ERR: index: %s", err)
+		ctx.Status(http.StatusInternalServerError)
+		return
 	}
+	// The page holds a nonce and the token of the visitor
+	ctx.Header("Cache-Control", "no-store")
 
+	lang := requestLang(ctx)
 	ctx.HTML(http.StatusOK, "index", gin.H{
-		"title":    "Index title!",
-		"cssFiles": cssFiles,
-		"jsFiles":  jsFiles,
+		"lang":     lang,
+		"title":    translator(lang, "index.title"),
+		"cssFiles": bundle.Styles,
+		"jsFiles":  bundle.Scripts,
+		"preloads": bundle.Preloads,
+		"module":   bundle.Module,
 		"rootID":   "root",
-		"add": func(a int, b int) int {
-			return a + b
-		},
+		"boot":     boot,
+		"nonce":    nonce,
 	})
 }
 
 // render only file, must full name with extension
 func emptyPage(ctx *gin.Context) {
-	ctx.HTML(http.StatusOK, "page.html", gin.H{"title": "Page file title!!"})
+	ctx.HTML(http.StatusOK, "page.html", gin.H{"title": "Page file title!!", "lang": requestLang(ctx)})
 }
 
 // Return application version
@@ -144,33 +269,78 @@ func version(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"Version": Version})
 }
 
-func notFound(ctx *gin.Context) {
-	// Any file path other than .html will be invalid.
-	invalidPath := regexp.MustCompile(`^\/?((?:\w+\/)*(\w*\.[^\.html]+))`)
-	if invalidPath.MatchString(ctx.Request.RequestURI) {
+// notFound answers the paths without route according to the routes config:
+// API paths get a JSON 404, pages are rendered, redirects are followed, files and unknown paths get a 404
+// and client routes get the index page so the frontend router handles them
+func notFound(reloader *settings.Reloader, findPage func(path string, langs []string) (*models.Page, []string, error), findRedirect func(path string) (*models.Redirect, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		cnf := reloader.Current()
+		routes := cnf.Routes
+		p := ctx.Request.URL.Path
+		switch {
+		case matchPrefix(p, routes.API):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+			return
+		case ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead:
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+
+		prefix, pagePath := splitLangPrefix(p, cnf.Languages)
+		if isFilePath(p) {
+			// Legacy URLs like /about.php are redirected too
+			if !redirectRequest(ctx, findRedirect, pagePath, prefix) {
+				ctx.Status(http.StatusNotFound)
+			}
+			return
+		}
+		langs := requestLanguages(ctx, cnf.Languages, prefix)
+		ctx.Set("Languages", langs)
+		page, available, err := findPage(pagePath, langs)
+		if err != nil {
+			log.Printf("ERR: notFound: %s", err)
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if page != nil {
+			if prefix == "" {
+				// The language is negotiated
+				ctx.Header("Vary", "Accept-Language, Cookie")
+			}
+			renderPage(ctx, page, alternateLinks(ctx, pagePath, available))
+			return
+		}
+
+		// Moved pages and the redirects of the admins, pages take precedence
+		if redirectRequest(ctx, findRedirect, pagePath, prefix) {
+			return
+		}
+
+		if matchPrefix(p, routes.Client) {
+			log.Printf("DEBUG: Path %s not found, defaulting to index.html", p)
+			index(ctx)
+			return
+		}
 		ctx.Status(http.StatusNotFound)
-		return
 	}
-	log.Printf("Path %s not found, defaulting to index.html\n", ctx.Request.URL.Path)
-	index(ctx)
 }
 
-func FilesPaths(pattern string) ([]string, error) {
-	var matches []string
-
-	fullPath, err := os.Getwd()
-	if err != nil {
-		return nil, err
-	}
-
-	matches, err = filepath.Glob(fullPath + pattern)
-	if err != nil {
-		return nil, err
+// matchPrefix tells if p is one of the prefixes or below one of them, "/app" matches "/app/list" but not "/apple"
+func matchPrefix(p string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
 	}
+	return false
+}
 
-	for i, _ := range matches {
-		matches[i] = strings.Replace(matches[i], fullPath, "", -1)
+// isFilePath tells if p is a missing static file or looks like a file, with an extension other than .html
+func isFilePath(p string) bool {
+	if matchPrefix(p, []string{publicPrefix}) {
+		return true
 	}
-
-	return matches, nil
+	ext := path.Ext(path.Base(p))
+	return ext != "" && ext != ".html"
 }
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotFound(t *testing.T) {
+	reloader := settings.NewReloader(&settings.Config{
+		Routes: settings.RoutesConfig{
+			API:    []string{"/api"},
+			Client: []string{"/app/", "/docs"},
+		},
+		Languages: settings.LanguagesConfig{Default: "en", Supported: []string{"en", "ru"}},
+	}, nil, nil)
+	router := gin.New()
+	router.HTMLRender = newViewEngine(fstest.MapFS{
+		"views/layouts/master.html": {Data: []byte(`{{template "content" .}}`)},
+		"views/index.html":          {Data: []byte(`{{define "content"}}index{{end}}`)},
+		"views/page.html":           {Data: []byte(`page {{ .title }}{{ range .alternates }} {{ .Lang }}={{ .URL }}{{ end }}`)},
+	}, false)
+	router.NoRoute(notFound(reloader, func(path string, langs []string) (*models.Page, []string, error) {
+		if path != "/app/terms" {
+			return nil, nil, nil
+		}
+		available := []string{"en", "ru"}
+		for _, lang := range langs {
+			if lang == "en" || lang == "ru" {
+				return &models.Page{Path: path, Lang: lang, Title: "Terms " + lang, Format: "markdown"}, available, nil
+			}
+		}
+		return nil, available, nil
+	}, func(path string) (*models.Redirect, error) {
+		return nil, nil
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/api/v2/unknown", http.StatusNotFound, `{"error":"route not found"}`},
+		{http.MethodPost, "/api", http.StatusNotFound, `{"error":"route not found"}`},
+		{http.MethodGet, "/app", http.StatusOK, "index"},
+		{http.MethodGet, "/app/markets/btcusd", http.StatusOK, "index"},
+		{http.MethodGet, "/app/terms", http.StatusOK, "page Terms en en=http://example.com/en/app/terms ru=http://example.com/ru/app/terms x-default=http://example.com/app/terms"},
+		{http.MethodGet, "/ru/app/terms", http.StatusOK, "page Terms ru en=http://example.com/en/app/terms ru=http://example.com/ru/app/terms x-default=http://example.com/app/terms"},
+		{http.MethodGet, "/fr/app/terms", http.StatusNotFound, ""},
+		{http.MethodGet, "/docs/intro.html", http.StatusOK, "index"},
+		{http.MethodGet, "/app/item.tml", http.StatusNotFound, ""},
+		{http.MethodGet, "/app/main.js", http.StatusNotFound, ""},
+		{http.MethodGet, "/public/assets/", http.StatusNotFound, ""},
+		{http.MethodGet, "/apple", http.StatusNotFound, ""},
+		{http.MethodPost, "/app/orders", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		assert.Equal(t, test.status, w.Code, test.path)
+		if test.body != "" {
+			assert.Equal(t, test.body, w.Body.String(), test.path)
+		}
+	}
+}
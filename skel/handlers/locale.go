@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+)
+
+// Cookie keeping the language chosen with the lang query parameter
+const langCookie = "lang"
+
+// Query parameter choosing the language of the page
+const langQuery = "lang"
+
+// alternateLink is a translation of the page, rendered as <link rel="alternate" hreflang>
+type alternateLink struct {
+	Lang string
+	URL  string
+}
+
+// splitLangPrefix returns the supported language the path starts with and the path without it,
+// f.e. "/ru/terms" gives "ru" and "/terms". The language is empty when there is no prefix.
+func splitLangPrefix(p string, cnf settings.LanguagesConfig) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(p, "/"), "/", 2)
+	if len(parts) < 2 || parts[1] == "" {
+		return "", p
+	}
+	lang := models.NormalizeLang(parts[0])
+	for _, supported := range cnf.Supported {
+		if models.NormalizeLang(supported) == lang {
+			return lang, "/" + parts[1]
+		}
+	}
+	return "", p
+}
+
+// requestLanguages returns the languages to serve a page in, by preference: the language of the URL prefix,
+// the lang query parameter, the lang cookie, the Accept-Language header, then the default language.
+// Every supported language is followed by its fallbacks, and the chain ends with the pages without language.
+// A language given by the query parameter is kept in the cookie.
+func requestLanguages(ctx *gin.Context, cnf settings.LanguagesConfig, prefix string) []string {
+	preferred := []string{}
+	if prefix != "" {
+		preferred = append(preferred, prefix)
+	}
+	if lang := supportedLanguage(ctx.Query(langQuery), cnf.Supported); lang != "" {
+		preferred = append(preferred, lang)
+		http.SetCookie(ctx.Writer, &http.Cookie{
+			Name:     langCookie,
+			Value:    lang,
+			Path:     "/",
+			MaxAge:   365 * 24 * 3600,
+			Secure:   ctx.Request.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	if cookie, err := ctx.Cookie(langCookie); err == nil {
+		preferred = append(preferred, cookie)
+	}
+	preferred = append(preferred, acceptedLanguages(ctx.GetHeader("Accept-Language"))...)
+	return languageChain(preferred, cnf)
+}
+
+// languageChain returns the supported languages of preferred with their fallbacks,
+// followed by the default language and the empty one of the pages without language
+func languageChain(preferred []string, cnf settings.LanguagesConfig) []string {
+	chain := []string{}
+	seen := map[string]bool{}
+	add := func(lang string) {
+		if !seen[lang] {
+			seen[lang] = true
+			chain = append(chain, lang)
+		}
+	}
+	for _, lang := range preferred {
+		lang = supportedLanguage(lang, cnf.Supported)
+		if lang == "" {
+			continue
+		}
+		add(lang)
+		for _, fallback := range cnf.Fallbacks[lang] {
+			add(models.NormalizeLang(fallback))
+		}
+	}
+	if cnf.Default != "" {
+		add(models.NormalizeLang(cnf.Default))
+	}
+	add("")
+	return chain
+}
+
+// supportedLanguage returns the supported language matching lang: the same one, its base language
+// or a regional variant of it, f.e. "pt-br" matches "pt" and "pt" matches "pt-br". It's empty when none matches.
+func supportedLanguage(lang string, supported []string) string {
+	lang = models.NormalizeLang(lang)
+	if lang == "" {
+		return ""
+	}
+	base := strings.SplitN(lang, "-", 2)[0]
+	match := ""
+	for _, s := range supported {
+		s = models.NormalizeLang(s)
+		switch {
+		case s == lang:
+			return s
+		case match == "" && (s == base || strings.HasPrefix(s, base+"-")):
+			match = s
+		}
+	}
+	return match
+}
+
+// acceptedLanguages returns the languages of an Accept-Language header by decreasing quality,
+// f.e. "ru, en;q=0.8, *;q=0.5" gives ru and en
+func acceptedLanguages(header string) []string {
+	type accepted struct {
+		lang    string
+		quality float64
+	}
+	list := []accepted{}
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		lang := strings.TrimSpace(params[0])
+		if lang == "" || lang == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			list = append(list, accepted{lang, quality})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].quality > list[j].quality
+	})
+	langs := make([]string, len(list))
+	for i, a := range list {
+		langs[i] = a.lang
+	}
+	return langs
+}
+
+// alternateLinks returns the links to the translations of the page at p, with x-default for the negotiated URL.
+// There are none unless the page is written in several languages.
+func alternateLinks(ctx *gin.Context, p string, langs []string) []alternateLink {
+	links := []alternateLink{}
+	for _, lang := range langs {
+		if lang != "" {
+			links = append(links, alternateLink{Lang: lang, URL: absoluteURL(ctx, "/"+lang+p)})
+		}
+	}
+	if len(links) < 2 {
+		return nil
+	}
+	return append(links, alternateLink{Lang: "x-default", URL: absoluteURL(ctx, p)})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitLangPrefix(t *testing.T) {
+	cnf := settings.LanguagesConfig{Supported: []string{"en", "pt-BR"}}
+	tests := []struct {
+		path, lang, rest string
+	}{
+		{"/en/terms", "en", "/terms"},
+		{"/pt-br/legal/terms", "pt-br", "/legal/terms"},
+		{"/PT_BR/terms", "pt-br", "/terms"},
+		{"/fr/terms", "", "/fr/terms"},
+		{"/en", "", "/en"},
+		{"/en/", "", "/en/"},
+		{"/terms", "", "/terms"},
+	}
+	for _, test := range tests {
+		lang, rest := splitLangPrefix(test.path, cnf)
+		assert.Equal(t, test.lang, lang, test.path)
+		assert.Equal(t, test.rest, rest, test.path)
+	}
+}
+
+func TestRequestLanguages(t *testing.T) {
+	cnf := settings.LanguagesConfig{
+		Default:   "en",
+		Supported: []string{"en", "ru", "uk", "pt-br"},
+		Fallbacks: map[string][]string{"uk": {"ru"}},
+	}
+	tests := []struct {
+		prefix string
+		query  string
+		cookie string
+		accept string
+		langs  []string
+	}{
+		{"", "", "", "", []string{"en", ""}},
+		{"", "", "", "uk, de;q=0.9, en;q=0.5", []string{"uk", "ru", "en", ""}},
+		{"", "", "", "ru;q=0.2, pt-PT;q=0.8, *", []string{"pt-br", "ru", "en", ""}},
+		{"", "", "ru", "uk", []string{"ru", "uk", "en", ""}},
+		{"", "RU", "uk", "", []string{"ru", "uk", "en", ""}},
+		{"uk", "de", "", "en", []string{"uk", "ru", "en", ""}},
+		{"", "", "", "ru;q=0", []string{"en", ""}},
+	}
+	for _, test := range tests {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/terms?lang="+test.query, nil)
+		if test.cookie != "" {
+			ctx.Request.AddCookie(&http.Cookie{Name: langCookie, Value: test.cookie})
+		}
+		ctx.Request.Header.Set("Accept-Language", test.accept)
+		assert.Equal(t, test.langs, requestLanguages(ctx, cnf, test.prefix), test)
+	}
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/terms?lang=pt", nil)
+	requestLanguages(ctx, cnf, "")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "lang=pt-br;")
+}
+
+func TestAlternateLinks(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/terms", nil)
+	ctx.Request.Header.Set("X-Forwarded-Proto", "https")
+
+	assert.Nil(t, alternateLinks(ctx, "/terms", []string{"", "en"}))
+	assert.Equal(t, []alternateLink{
+		{Lang: "en", URL: "https://example.com/en/terms"},
+		{Lang: "ru", URL: "https://example.com/ru/terms"},
+		{Lang: "x-default", URL: "https://example.com/terms"},
+	}, alternateLinks(ctx, "/terms", []string{"", "en", "ru"}))
+}
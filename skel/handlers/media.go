@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/media"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+)
+
+// mediaPrefix is the path the files of the local storage are served at
+const mediaPrefix = "/media"
+
+// Pagination of the media library
+const (
+	defaultMediaLimit = 20
+	maxMediaLimit     = 100
+)
+
+// mediaFormSize is the room left for the fields of the upload form besides the file
+const mediaFormSize = 64 << 10
+
+// maxMediaAlt is the number of characters of the alternative text, the size of its column
+const maxMediaAlt = 255
+
+// mediaStorage keeps the files of the media library, set up by Setup
+var mediaStorage media.Storage = media.NewLocalStorage("storage/media", mediaPrefix)
+
+// newMediaStorage returns the storage of the media driver
+func newMediaStorage(cnf settings.MediaConfig) (media.Storage, error) {
+	switch cnf.Driver {
+	case settings.MediaLocal, "":
+		return media.NewLocalStorage(cnf.Dir, mediaPrefix), nil
+	}
+	return nil, fmt.Errorf("unknown media driver %q", cnf.Driver)
+}
+
+// serveMedia serves the files of the local storage, they are never changed so browsers keep them
+func serveMedia(router *gin.Engine, storage media.Storage) {
+	local, ok := storage.(*media.LocalStorage)
+	if !ok {
+		return
+	}
+	router.Group(mediaPrefix, func(c *gin.Context) {
+		c.Header("Cache-Control", immutableCache)
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Next()
+	}).Static("/", local.Dir)
+}
+
+type mediaVariantEntity struct {
+	URL    string `json:"url"`
+	Type   string `json:"type"`
+	Size   int64  `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type mediaEntity struct {
+	ID        uint                 `json:"id"`
+	URL       string               `json:"url"`
+	Name      string               `json:"name"`
+	Type      string               `json:"type"`
+	Size      int64                `json:"size"`
+	Width     int                  `json:"width,omitempty"`
+	Height    int                  `json:"height,omitempty"`
+	Alt       string               `json:"alt"`
+	Author    string               `json:"author"`
+	Variants  []mediaVariantEntity `json:"variants"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+func newMediaEntity(m *models.Media) mediaEntity {
+	variants := make([]mediaVariantEntity, len(m.Variants))
+	for i, v := range m.Variants {
+		variants[i] = mediaVariantEntity{URL: mediaStorage.URL(v.Key), Type: v.Type, Size: v.Size, Width: v.Width, Height: v.Height}
+	}
+	return mediaEntity{
+		ID:        m.ID,
+		URL:       mediaStorage.URL(m.Key),
+		Name:      m.Name,
+		Type:      m.Type,
+		Size:      m.Size,
+		Width:     m.Width,
+		Height:    m.Height,
+		Alt:       m.Alt,
+		Author:    m.Author,
+		Variants:  variants,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// ListMedia handles GET '/api/v2/admin/media'
+// Media are listed latest first, paginated with page and limit, the Total header has their number
+func ListMedia(ctx *gin.Context) {
+	page, err := queryInt(ctx, "page", 1, 1, 0)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := queryInt(ctx, "limit", defaultMediaLimit, 1, maxMediaLimit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, total, err := models.ListMedia((page-1)*limit, limit)
+	if err != nil {
+		log.Printf("ERR: ListMedia: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entities := make([]mediaEntity, len(list))
+	for i := range list {
+		entities[i] = newMediaEntity(&list[i])
+	}
+	ctx.Header("Total", strconv.FormatInt(total, 10))
+	ctx.Header("Page", strconv.Itoa(page))
+	ctx.Header("Per-Page", strconv.Itoa(limit))
+	ctx.JSON(http.StatusOK, entities)
+}
+
+// GetMedia handles GET '/api/v2/admin/media/:id'
+func GetMedia(ctx *gin.Context) {
+	m, ok := findMediaParam(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, newMediaEntity(m))
+}
+
+// UploadMedia handles POST '/api/v2/admin/media'
+// The multipart form has the file in "file" and the alternative text of images in "alt".
+// The type is detected from the content, images get their resized variants.
+func UploadMedia(ctx *gin.Context) {
+	cnf, err := GetSettings(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	maxSize := cnf.Media.MaxSize
+	tooLarge := gin.H{"error": fmt.Sprintf("file is larger than %d bytes", maxSize)}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+mediaFormSize)
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			ctx.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if header.Size > maxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		log.Printf("ERR: UploadMedia: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	file.Close()
+	if err != nil {
+		log.Printf("ERR: UploadMedia: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if int64(len(data)) > maxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+		return
+	}
+	alt := ctx.PostForm("alt")
+	if utf8.RuneCountInString(alt) > maxMediaAlt {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("alt is longer than %d characters", maxMediaAlt)})
+		return
+	}
+
+	contentType := media.DetectType(data)
+	ext, known := media.Extensions[contentType]
+	if !known || !contains(cnf.Media.Types, contentType) {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("unsupported type %s, expected one of %s", contentType, strings.Join(cnf.Media.Types, ", "))})
+		return
+	}
+	width, height, _, err := media.ImageSize(contentType, data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	variants, err := media.Variants(contentType, data, cnf.Media.Widths)
+	if err != nil {
+		if errors.Is(err, media.ErrNotImage) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("ERR: UploadMedia: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	base, err := mediaKey(time.Now())
+	if err != nil {
+		log.Printf("ERR: UploadMedia: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	m := models.Media{
+		Key:    base + ext,
+		Name:   mediaName(header.Filename),
+		Type:   contentType,
+		Size:   int64(len(data)),
+		Width:  width,
+		Height: height,
+		Alt:    alt,
+		Author: author(ctx),
+	}
+	if err := mediaStorage.Put(m.Key, bytes.NewReader(data)); err != nil {
+		log.Printf("ERR: UploadMedia: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, v := range variants {
+		variant := models.MediaVariant{
+			Key:    fmt.Sprintf("%s-%dw%s", base, v.Width, media.Extensions[v.Type]),
+			Type:   v.Type,
+			Size:   int64(len(v.Data)),
+			Width:  v.Width,
+			Height: v.Height,
+		}
+		m.Variants = append(m.Variants, variant)
+		err = mediaStorage.Put(variant.Key, bytes.NewReader(v.Data))
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = models.CreateMedia(&m)
+	}
+	if err != nil {
+		log.Printf("ERR: UploadMedia: %s", err)
+		removeMediaFiles(&m)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, newMediaEntity(&m))
+}
+
+// DeleteMedia handles DELETE '/api/v2/admin/media/:id'
+// Pages still referencing the file get broken images, the files are removed with the record
+func DeleteMedia(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	m, err := models.DeleteMedia(uint(id))
+	if err != nil {
+		log.Printf("ERR: DeleteMedia: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if m == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+		return
+	}
+	removeMediaFiles(m)
+	ctx.Status(http.StatusNoContent)
+}
+
+func findMediaParam(ctx *gin.Context) (*models.Media, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+	m, err := models.FindMedia(uint(id))
+	if err != nil {
+		log.Printf("ERR: FindMedia: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if m == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+		return nil, false
+	}
+	return m, true
+}
+
+// removeMediaFiles deletes the files of a media, failures leave orphan files and are only logged
+func removeMediaFiles(m *models.Media) {
+	for _, key := range m.Keys() {
+		if err := mediaStorage.Delete(key); err != nil {
+			log.Printf("WARN: removeMediaFiles: %s", err)
+		}
+	}
+}
+
+// mediaKey returns a new key without extension, files are grouped by month and named randomly
+// so uploads never replace each other and their URLs can be cached forever
+func mediaKey(t time.Time) (string, error) {
+	raw, err := randomBytes(12)
+	if err != nil {
+		return "", err
+	}
+	return t.UTC().Format("2006/01/") + hex.EncodeToString(raw), nil
+}
+
+// mediaName returns the base name of an uploaded file, within the size of its column
+func mediaName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
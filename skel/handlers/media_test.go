@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/media"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mediaRouter(t *testing.T) (*gin.Engine, string) {
+	dir := t.TempDir()
+	mediaStorage = media.NewLocalStorage(dir, mediaPrefix)
+	reloader := settings.NewReloader(&settings.Config{
+		Media: settings.MediaConfig{MaxSize: 4096, Types: []string{"image/png", "application/pdf"}, Widths: []int{16, 64}},
+	}, nil, nil)
+	router := gin.New()
+	serveMedia(router, mediaStorage)
+	admin := router.Group("/api/v2/admin", SettingsMiddleware(reloader))
+	admin.GET("/media", ListMedia)
+	admin.GET("/media/:id", GetMedia)
+	admin.POST("/media", UploadMedia)
+	admin.DELETE("/media/:id", DeleteMedia)
+	return router, dir
+}
+
+func upload(router *gin.Engine, name string, data []byte) *httptest.ResponseRecorder {
+	return uploadAlt(router, name, "Logo", data)
+}
+
+func uploadAlt(router *gin.Engine, name, alt string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("alt", alt)
+	part, _ := form.CreateFormFile("file", name)
+	part.Write(data)
+	form.Close()
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/admin/media", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestMediaAPI(t *testing.T) {
+	initTestDB(t)
+	router, dir := mediaRouter(t)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 32, 20))))
+
+	w := upload(router, `C:\images\logo.jpg`, buf.Bytes())
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := mediaEntity{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "logo.jpg", created.Name)
+	assert.Equal(t, "image/png", created.Type)
+	assert.Equal(t, "Logo", created.Alt)
+	assert.Equal(t, []int{32, 20}, []int{created.Width, created.Height})
+	assert.Regexp(t, `^/media/\d{4}/\d{2}/[0-9a-f]{24}\.png$`, created.URL)
+	require.Len(t, created.Variants, 1)
+	assert.Equal(t, 16, created.Variants[0].Width)
+	assert.Equal(t, created.URL[:len(created.URL)-4]+"-16w.png", created.Variants[0].URL)
+
+	served := httptest.NewRecorder()
+	router.ServeHTTP(served, httptest.NewRequest(http.MethodGet, created.Variants[0].URL, nil))
+	assert.Equal(t, http.StatusOK, served.Code)
+	assert.Equal(t, "nosniff", served.Header().Get("X-Content-Type-Options"))
+
+	pdf := upload(router, "terms.pdf", []byte("%PDF-1.4\n"))
+	require.Equal(t, http.StatusCreated, pdf.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/media?limit=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Total"))
+	assert.Contains(t, w.Body.String(), `"name":"terms.pdf"`)
+
+	w = request(router, http.MethodGet, "/api/v2/admin/media/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"logo.jpg"`)
+
+	w = upload(router, "page.html", []byte("<html><script>alert(1)</script></html>"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Equal(t, `{"error":"unsupported type text/html; charset=utf-8, expected one of image/png, application/pdf"}`, w.Body.String())
+	w = upload(router, "large.pdf", append([]byte("%PDF-1.4\n"), make([]byte, 4096)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, `{"error":"file is larger than 4096 bytes"}`, w.Body.String())
+	w = upload(router, "broken.png", []byte("\x89PNG\r\n\x1a\nbroken"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = uploadAlt(router, "terms.pdf", strings.Repeat("é", 256), []byte("%PDF-1.4\n"))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"error":"alt is longer than 255 characters"}`, w.Body.String())
+
+	w = request(router, http.MethodDelete, "/api/v2/admin/media/1", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/media/1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*", "*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, ".pdf", filepath.Ext(files[0]))
+	_, err = os.Stat(files[0])
+	assert.NoError(t, err)
+}
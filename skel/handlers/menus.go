@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+)
+
+// menuItemEntity is an item of a menu in the admin API, items are sent and returned as a tree
+type menuItemEntity struct {
+	Label    string            `json:"label"`
+	Labels   map[string]string `json:"labels"`
+	Path     string            `json:"path,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Children []menuItemEntity  `json:"children"`
+}
+
+type menuParams struct {
+	Name  string           `json:"name" binding:"required"`
+	Items []menuItemEntity `json:"items"`
+}
+
+type menuEntity struct {
+	ID        uint             `json:"id"`
+	Name      string           `json:"name"`
+	Items     []menuItemEntity `json:"items,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// menuLink is an item of a menu in its language, used by the views and the public API
+type menuLink struct {
+	Label    string     `json:"label"`
+	URL      string     `json:"url"`
+	External bool       `json:"external"`
+	Children []menuLink `json:"children"`
+}
+
+func newMenuEntity(menu *models.Menu) menuEntity {
+	return menuEntity{
+		ID:        menu.ID,
+		Name:      menu.Name,
+		Items:     newMenuItemEntities(menu.Items),
+		CreatedAt: menu.CreatedAt,
+		UpdatedAt: menu.UpdatedAt,
+	}
+}
+
+func newMenuItemEntities(items []models.MenuItem) []menuItemEntity {
+	if items == nil {
+		return nil
+	}
+	entities := make([]menuItemEntity, len(items))
+	for i, item := range items {
+		entities[i] = menuItemEntity{
+			Label:    item.Label,
+			Labels:   item.Labels,
+			Path:     item.Path,
+			URL:      item.URL,
+			Children: newMenuItemEntities(item.Children),
+		}
+		if entities[i].Children == nil {
+			entities[i].Children = []menuItemEntity{}
+		}
+	}
+	return entities
+}
+
+func menuItems(entities []menuItemEntity) []models.MenuItem {
+	items := make([]models.MenuItem, len(entities))
+	for i, entity := range entities {
+		items[i] = models.MenuItem{
+			Label:    entity.Label,
+			Labels:   entity.Labels,
+			Path:     entity.Path,
+			URL:      entity.URL,
+			Children: menuItems(entity.Children),
+		}
+	}
+	return items
+}
+
+// newMenuLinks returns the items in the first of langs they have a label in
+func newMenuLinks(items []models.MenuItem, langs ...string) []menuLink {
+	links := make([]menuLink, len(items))
+	for i := range items {
+		item := &items[i]
+		links[i] = menuLink{
+			Label:    item.Text(langs...),
+			URL:      item.Path,
+			Children: newMenuLinks(item.Children, langs...),
+		}
+		if item.URL != "" {
+			links[i].URL, links[i].External = item.URL, true
+		}
+	}
+	return links
+}
+
+// menuLinks returns the menu name in lang for the views, {{ range menu "footer" .lang }}.
+// Missing menus have no links, errors are logged so the page is still shown.
+func menuLinks(name, lang string) []menuLink {
+	menu, err := models.FindMenuByName(name)
+	if err != nil {
+		log.Printf("ERR: menuLinks: %s", err)
+		return nil
+	}
+	if menu == nil {
+		return nil
+	}
+	return newMenuLinks(menu.Items, lang)
+}
+
+// ListMenus handles GET '/api/v2/admin/menus'
+// Menus are listed by name without their items
+func ListMenus(ctx *gin.Context) {
+	menus, err := models.ListMenus()
+	if err != nil {
+		log.Printf("ERR: ListMenus: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	entities := make([]menuEntity, len(menus))
+	for i := range menus {
+		entities[i] = newMenuEntity(&menus[i])
+	}
+	ctx.JSON(http.StatusOK, entities)
+}
+
+// GetMenu handles GET '/api/v2/admin/menus/:id'
+func GetMenu(ctx *gin.Context) {
+	menu, ok := findMenuParam(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, newMenuEntity(menu))
+}
+
+// CreateMenu handles POST '/api/v2/admin/menus'
+func CreateMenu(ctx *gin.Context) {
+	menu := &models.Menu{}
+	if !bindMenu(ctx, menu) {
+		return
+	}
+	if saveMenu(ctx, menu) {
+		ctx.JSON(http.StatusCreated, newMenuEntity(menu))
+	}
+}
+
+// UpdateMenu handles PUT '/api/v2/admin/menus/:id'
+// The items of the request replace the ones of the menu
+func UpdateMenu(ctx *gin.Context) {
+	menu, ok := findMenuParam(ctx)
+	if !ok || !bindMenu(ctx, menu) {
+		return
+	}
+	if saveMenu(ctx, menu) {
+		ctx.JSON(http.StatusOK, newMenuEntity(menu))
+	}
+}
+
+// DeleteMenu handles DELETE '/api/v2/admin/menus/:id'
+func DeleteMenu(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	found, err := models.DeleteMenu(uint(id))
+	if err != nil {
+		log.Printf("ERR: DeleteMenu: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetPublicMenu handles GET '/api/v2/public/menus/:name'
+// Labels are in the language of the lang query parameter when the items have one
+func GetPublicMenu(ctx *gin.Context) {
+	menu, err := models.FindMenuByName(ctx.Param("name"))
+	if err != nil {
+		log.Printf("ERR: GetPublicMenu: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if menu == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age=60")
+	ctx.JSON(http.StatusOK, newMenuLinks(menu.Items, ctx.Query("lang")))
+}
+
+func findMenuParam(ctx *gin.Context) (*models.Menu, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+	menu, err := models.FindMenu(uint(id))
+	if err != nil {
+		log.Printf("ERR: FindMenu: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if menu == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+		return nil, false
+	}
+	return menu, true
+}
+
+// bindMenu reads the request into the menu, the items are validated before saving
+func bindMenu(ctx *gin.Context, menu *models.Menu) bool {
+	params := menuParams{}
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	menu.Name = strings.TrimSpace(params.Name)
+	menu.Items = menuItems(params.Items)
+	if err := menu.Validate(); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func saveMenu(ctx *gin.Context, menu *models.Menu) bool {
+	err := models.SaveMenu(menu)
+	if err == models.ErrMenuNameTaken {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		log.Printf("ERR: SaveMenu: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
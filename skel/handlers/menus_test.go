@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func menusRouter() *gin.Engine {
+	router := gin.New()
+	admin := router.Group("/api/v2/admin")
+	admin.GET("/menus", ListMenus)
+	admin.GET("/menus/:id", GetMenu)
+	admin.POST("/menus", CreateMenu)
+	admin.PUT("/menus/:id", UpdateMenu)
+	admin.DELETE("/menus/:id", DeleteMenu)
+	router.GET("/api/v2/public/menus/:name", GetPublicMenu)
+	return router
+}
+
+func TestMenusAPI(t *testing.T) {
+	initTestDB(t)
+	router := menusRouter()
+
+	footer := gin.H{"name": "footer", "items": []gin.H{
+		{"label": "Legal", "path": "/legal", "children": []gin.H{
+			{"label": "Terms", "labels": gin.H{"ru": "Условия"}, "path": "/terms"},
+		}},
+		{"label": "Openware", "url": "https://www.openware.com"},
+	}}
+	w := request(router, http.MethodPost, "/api/v2/admin/menus", footer)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"items":[{"label":"Legal","labels":{},"path":"/legal","children":[{"label":"Terms","labels":{"ru":"Условия"},"path":"/terms","children":[]}]},{"label":"Openware","labels":{},"url":"https://www.openware.com","children":[]}]`)
+
+	w = request(router, http.MethodPost, "/api/v2/admin/menus", footer)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = request(router, http.MethodPost, "/api/v2/admin/menus", gin.H{"name": "header", "items": []gin.H{{"label": "Home"}}})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"error":"menu item \"Home\" needs either a path or a url"}`, w.Body.String())
+
+	w = request(router, http.MethodGet, "/api/v2/public/menus/footer?lang=ru", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `[{"label":"Legal","url":"/legal","external":false,"children":[{"label":"Условия","url":"/terms","external":false,"children":[]}]},{"label":"Openware","url":"https://www.openware.com","external":true,"children":[]}]`, w.Body.String())
+	w = request(router, http.MethodGet, "/api/v2/public/menus/header", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = request(router, http.MethodPut, "/api/v2/admin/menus/1", gin.H{"name": "footer", "items": []gin.H{{"label": "FAQ", "path": "/faq"}}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/menus", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Regexp(t, `^\[\{"id":1,"name":"footer","created_at":"[^"]+","updated_at":"[^"]+"\}\]$`, w.Body.String())
+	w = request(router, http.MethodGet, "/api/v2/admin/menus/1", nil)
+	assert.Contains(t, w.Body.String(), `"items":[{"label":"FAQ","labels":{},"path":"/faq","children":[]}]`)
+
+	w = request(router, http.MethodDelete, "/api/v2/admin/menus/1", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/menus/1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestFooterMenu(t *testing.T) {
+	initTestDB(t)
+	require.NoError(t, models.SavePage(&models.Page{Path: "/terms", Title: "Terms"}, ""))
+	require.NoError(t, models.SaveMenu(&models.Menu{Name: "footer", Items: []models.MenuItem{
+		{Label: "Terms", Path: "/terms"},
+		{Label: "GitHub", URL: "https://github.com/openware"},
+	}}))
+	router := pagesRouter()
+	router.NoRoute(notFound(settings.NewReloader(&settings.Config{}, nil, nil), models.FindPageByPath, models.FindRedirect))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/terms", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<li><a href="/terms">Terms</a></li>`)
+	assert.Contains(t, w.Body.String(), `<li><a href="https://github.com/openware" target="_blank" rel="noopener">GitHub</a></li>`)
+}
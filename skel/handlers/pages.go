@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/content"
+	"github.com/openware/sonic/skel/models"
+)
+
+// Pagination of the page list
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Rendered page bodies by page version
+var pageCache = content.NewCache()
+
+type pageParams struct {
+	Path        string `json:"path" binding:"required"`
+	Lang        string `json:"lang"`
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	Body        string `json:"body"`
+	Format      string `json:"format"`
+	// SEO metadata
+	Keywords      string `json:"keywords"`
+	CanonicalURL  string `json:"canonical_url"`
+	OGTitle       string `json:"og_title"`
+	OGDescription string `json:"og_description"`
+	OGImage       string `json:"og_image"`
+	TwitterCard   string `json:"twitter_card"`
+	NoIndex       bool   `json:"noindex"`
+	// Status defaults to draft, pages are published explicitly
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// pageEntity is the page returned by the admin API
+type pageEntity struct {
+	ID            uint       `json:"id"`
+	Path          string     `json:"path"`
+	Lang          string     `json:"lang"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Body          string     `json:"body"`
+	Keywords      string     `json:"keywords"`
+	CanonicalURL  string     `json:"canonical_url"`
+	OGTitle       string     `json:"og_title"`
+	OGDescription string     `json:"og_description"`
+	OGImage       string     `json:"og_image"`
+	TwitterCard   string     `json:"twitter_card"`
+	NoIndex       bool       `json:"noindex"`
+	Format        string     `json:"format"`
+	Version       uint       `json:"version"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at"`
+	UnpublishAt   *time.Time `json:"unpublish_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func newPageEntity(p *models.Page) pageEntity {
+	return pageEntity{
+		ID:            p.ID,
+		Path:          p.Path,
+		Lang:          p.Lang,
+		Title:         p.Title,
+		Description:   p.Description,
+		Body:          p.Body,
+		Keywords:      p.Keywords,
+		CanonicalURL:  p.CanonicalURL,
+		OGTitle:       p.OGTitle,
+		OGDescription: p.OGDescription,
+		OGImage:       p.OGImage,
+		TwitterCard:   p.TwitterCard,
+		NoIndex:       p.NoIndex,
+		Format:        p.Format,
+		Version:       p.Version,
+		Status:        p.Status,
+		PublishAt:     p.PublishAt,
+		UnpublishAt:   p.UnpublishAt,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+// renderPage renders the page with views/page.html, alternates are its translations
+func renderPage(ctx *gin.Context, page *models.Page, alternates []alternateLink) {
+	rendered, err := pageCache.Get(page.ID, page.Version, page.Render)
+	if err != nil {
+		log.Printf("ERR: renderPage %s: %s", page.Path, err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	if page.Lang != "" {
+		ctx.Header("Content-Language", page.Lang)
+	}
+	if page.NoIndex {
+		ctx.Header("X-Robots-Tag", "noindex")
+	}
+	ctx.HTML(http.StatusOK, "page.html", gin.H{
+		"alternates":  alternates,
+		"meta":        newPageMeta(ctx, page, alternates != nil),
+		"lang":        requestLang(ctx),
+		"title":       page.Title,
+		"description": page.Description,
+		"language":    page.Lang,
+		"body":        rendered.HTML,
+		"toc":         rendered.TOC,
+	})
+}
+
+// ListPages handles GET '/api/v2/admin/pages?lang=&page=&limit='
+// The total number of pages is returned in the Total header
+func ListPages(ctx *gin.Context) {
+	page, err := queryInt(ctx, "page", 1, 1, 0)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := queryInt(ctx, "limit", defaultPageLimit, 1, maxPageLimit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pages, total, err := models.ListPages(ctx.Query("lang"), (page-1)*limit, limit)
+	if err != nil {
+		log.Printf("ERR: ListPages: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entities := make([]pageEntity, len(pages))
+	for i := range pages {
+		entities[i] = newPageEntity(&pages[i])
+	}
+	ctx.Header("Total", strconv.FormatInt(total, 10))
+	ctx.Header("Page", strconv.Itoa(page))
+	ctx.Header("Per-Page", strconv.Itoa(limit))
+	ctx.JSON(http.StatusOK, entities)
+}
+
+// GetPage handles GET '/api/v2/admin/pages/:id'
+func GetPage(ctx *gin.Context) {
+	page, ok := findPageParam(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, newPageEntity(page))
+}
+
+// CreatePage handles POST '/api/v2/admin/pages'
+func CreatePage(ctx *gin.Context) {
+	page := &models.Page{}
+	if !bindPage(ctx, page) {
+		return
+	}
+	if savePage(ctx, page) {
+		ctx.JSON(http.StatusCreated, newPageEntity(page))
+	}
+}
+
+// UpdatePage handles PUT '/api/v2/admin/pages/:id', every field is replaced except the status when it's missing
+func UpdatePage(ctx *gin.Context) {
+	page, ok := findPageParam(ctx)
+	if !ok || !bindPage(ctx, page) {
+		return
+	}
+	if savePage(ctx, page) {
+		ctx.JSON(http.StatusOK, newPageEntity(page))
+	}
+}
+
+// DeletePage handles DELETE '/api/v2/admin/pages/:id'
+func DeletePage(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	found, err := models.DeletePage(uint(id))
+	if err != nil {
+		log.Printf("ERR: DeletePage: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "page not found"})
+		return
+	}
+	pageCache.Delete(uint(id))
+	ctx.Status(http.StatusNoContent)
+}
+
+// findPageParam returns the page of the id parameter, the error response is sent when there is none
+func findPageParam(ctx *gin.Context) (*models.Page, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+	page, err := models.FindPage(uint(id))
+	if err != nil {
+		log.Printf("ERR: FindPage: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if page == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "page not found"})
+		return nil, false
+	}
+	return page, true
+}
+
+// bindPage sets the fields of the request body to the page and validates it
+func bindPage(ctx *gin.Context, page *models.Page) bool {
+	params := pageParams{}
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	page.Path = params.Path
+	page.Lang = models.NormalizeLang(params.Lang)
+	page.Title = params.Title
+	page.Description = params.Description
+	page.Body = params.Body
+	page.Keywords = params.Keywords
+	page.CanonicalURL = params.CanonicalURL
+	page.OGTitle = params.OGTitle
+	page.OGDescription = params.OGDescription
+	page.OGImage = params.OGImage
+	page.TwitterCard = params.TwitterCard
+	page.NoIndex = params.NoIndex
+	page.Format = params.Format
+	if page.Format == "" {
+		page.Format = content.FormatMarkdown
+	}
+	// An update without status keeps the current one, so a live page isn't unpublished
+	if params.Status != "" {
+		page.Status = params.Status
+	}
+	if page.Status == "" {
+		page.Status = models.PageDraft
+	}
+	page.PublishAt = params.PublishAt
+	page.UnpublishAt = params.UnpublishAt
+
+	err := page.Validate()
+	if err == nil && reservedPath(ctx, page.Path) {
+		err = fmt.Errorf("path %s is reserved", page.Path)
+	}
+	if err == nil && !supportedPageLang(ctx, page.Lang) {
+		err = fmt.Errorf("language %s is not in languages.supported", page.Lang)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// reservedPath tells if pages can't be served at path, the API, the public files, the media, the previews
+// and the other GET routes of the router have precedence
+func reservedPath(ctx *gin.Context, path string) bool {
+	if matchPrefix(path, []string{publicPrefix, mediaPrefix, previewPrefix}) {
+		return true
+	}
+	if router, ok := ctx.Get("Router"); ok {
+		for _, route := range router.(*gin.Engine).Routes() {
+			if route.Method == http.MethodGet && matchRoute(route.Path, path) {
+				return true
+			}
+		}
+	}
+	if cnf, err := GetSettings(ctx); err == nil {
+		return matchPrefix(path, cnf.Routes.API)
+	}
+	return false
+}
+
+// supportedPageLang tells if a page in lang can be served, its language is empty or one of languages.supported
+func supportedPageLang(ctx *gin.Context, lang string) bool {
+	cnf, err := GetSettings(ctx)
+	if err != nil || lang == "" {
+		return true
+	}
+	for _, supported := range cnf.Languages.Supported {
+		if models.NormalizeLang(supported) == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute tells if a route pattern like /preview/:token or /public/*filepath matches path
+func matchRoute(pattern, path string) bool {
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
+
+func savePage(ctx *gin.Context, page *models.Page) bool {
+	err := models.SavePage(page, author(ctx))
+	if err == models.ErrPathTaken {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		log.Printf("ERR: SavePage: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// queryInt reads an integer query parameter between min and max, max 0 being unlimited
+func queryInt(ctx *gin.Context, name string, def, min, max int) (int, error) {
+	raw, ok := ctx.GetQuery(name)
+	if !ok {
+		return def, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < min || (max > 0 && value > max) {
+		if max > 0 {
+			return 0, fmt.Errorf("invalid %s, expected %d to %d", name, min, max)
+		}
+		return 0, fmt.Errorf("invalid %s, expected %d or more", name, min)
+	}
+	return value, nil
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/pkg/sonic/config"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// initTestDB sets up the models with an in-memory database, a single connection keeps the same database
+func initTestDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sql, err := db.DB()
+	require.NoError(t, err)
+	sql.SetMaxOpenConns(1)
+	models.Setup(&config.Runtime{DB: db})
+	require.NoError(t, models.Migrate())
+}
+
+func pagesRouter() *gin.Engine {
+	reloader := settings.NewReloader(&settings.Config{
+		Routes:    settings.RoutesConfig{API: []string{"/api"}},
+		Pages:     settings.PagesConfig{PreviewSecret: "secret", PreviewTTL: time.Hour},
+		Languages: settings.LanguagesConfig{Default: "en", Supported: []string{"en", "fr"}},
+	}, nil, nil)
+	router := gin.New()
+	router.HTMLRender = newViewEngine(os.DirFS(".."), false)
+	router.GET("/version", version)
+	router.GET("/sitemap.xml", SettingsMiddleware(reloader), Sitemap)
+	router.GET(previewPrefix+"/:token", SettingsMiddleware(reloader), PreviewPage)
+	admin := router.Group("/api/v2/admin", SettingsMiddleware(reloader), RouterMiddleware(router))
+	admin.GET("/pages", ListPages)
+	admin.GET("/pages/:id", GetPage)
+	admin.POST("/pages", CreatePage)
+	admin.PUT("/pages/:id", UpdatePage)
+	admin.DELETE("/pages/:id", DeletePage)
+	admin.POST("/pages/:id/preview", CreatePagePreview)
+	admin.GET("/pages/:id/revisions", ListPageRevisions)
+	admin.GET("/pages/:id/revisions/:version", GetPageRevision)
+	admin.POST("/pages/:id/revisions/:version/restore", RestorePageRevision)
+	admin.GET("/pages/:id/diff", GetPageDiff)
+	return router
+}
+
+func request(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	raw, _ := json.Marshal(body)
+	r := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestPagesAPI(t *testing.T) {
+	initTestDB(t)
+	router := pagesRouter()
+
+	w := request(router, http.MethodPost, "/api/v2/admin/pages", gin.H{"path": "/legal/terms", "lang": "en", "title": "Terms", "body": "# Terms"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := pageEntity{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "/legal/terms", created.Path)
+	assert.Equal(t, "markdown", created.Format)
+	assert.Equal(t, uint(1), created.Version)
+	assert.Equal(t, models.PageDraft, created.Status)
+
+	w = request(router, http.MethodPost, "/api/v2/admin/pages", gin.H{"path": "/faq", "lang": "fr", "title": "FAQ"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = request(router, http.MethodPost, "/api/v2/admin/pages", gin.H{"path": "/legal/terms", "lang": "EN", "title": "Copy"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages?limit=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Total"))
+	list := []pageEntity{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, "/faq", list[0].Path)
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages?lang=en&page=1", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, "/legal/terms", list[0].Path)
+	assert.Equal(t, "1", w.Header().Get("Total"))
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages?limit=500", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(router, http.MethodPut, "/api/v2/admin/pages/1", gin.H{"path": "/legal/tos", "lang": "en", "title": "Terms", "status": "published"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = request(router, http.MethodPut, "/api/v2/admin/pages/1", gin.H{"path": "/legal/tos", "lang": "en", "title": "Terms of service", "format": "plain"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := pageEntity{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "/legal/tos", updated.Path)
+	assert.Equal(t, uint(3), updated.Version)
+	assert.Equal(t, models.PagePublished, updated.Status)
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Terms of service"`)
+
+	w = request(router, http.MethodDelete, "/api/v2/admin/pages/1", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(router, http.MethodDelete, "/api/v2/admin/pages/1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/abc", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPagesAPIValidation(t *testing.T) {
+	initTestDB(t)
+	router := pagesRouter()
+
+	tests := []struct {
+		params gin.H
+		status int
+		err    string
+	}{
+		{gin.H{"title": "No path"}, http.StatusBadRequest, "Path"},
+		{gin.H{"path": "terms", "title": "Relative"}, http.StatusUnprocessableEntity, "invalid path"},
+		{gin.H{"path": "/legal//terms", "title": "Empty segment"}, http.StatusUnprocessableEntity, "invalid path"},
+		{gin.H{"path": "/terms/", "title": "Trailing slash"}, http.StatusUnprocessableEntity, "invalid path"},
+		{gin.H{"path": "/terms?x=1", "title": "Query"}, http.StatusUnprocessableEntity, "invalid path"},
+		{gin.H{"path": "/" + strings.Repeat("a", 64), "title": "Long"}, http.StatusUnprocessableEntity, "longer than 64"},
+		{gin.H{"path": "/terms", "title": "Format", "format": "rst"}, http.StatusUnprocessableEntity, "invalid page format"},
+		{gin.H{"path": "/api/pages", "title": "API"}, http.StatusUnprocessableEntity, "reserved"},
+		{gin.H{"path": "/public/terms", "title": "Public"}, http.StatusUnprocessableEntity, "reserved"},
+		{gin.H{"path": "/preview/terms", "title": "Preview"}, http.StatusUnprocessableEntity, "reserved"},
+		{gin.H{"path": "/media/2021/logo", "title": "Media"}, http.StatusUnprocessableEntity, "reserved"},
+		{gin.H{"path": "/version", "title": "Version"}, http.StatusUnprocessableEntity, "reserved"},
+		{gin.H{"path": "/sitemap.xml", "title": "Sitemap"}, http.StatusUnprocessableEntity, "reserved"},
+		{gin.H{"path": "/sitemap.xml/en", "title": "Below a route"}, http.StatusCreated, ""},
+		{gin.H{"path": "/terms", "lang": "de", "title": "Language"}, http.StatusUnprocessableEntity, "language de is not in languages.supported"},
+		{gin.H{"path": "/terms", "title": "Status", "status": "hidden"}, http.StatusUnprocessableEntity, "invalid page status"},
+		{gin.H{"path": "/terms", "title": "Scheduled", "status": "scheduled"}, http.StatusUnprocessableEntity, "publish_at is required"},
+	}
+	for _, test := range tests {
+		w := request(router, http.MethodPost, "/api/v2/admin/pages", test.params)
+		assert.Equal(t, test.status, w.Code, test.params)
+		assert.Contains(t, w.Body.String(), test.err, test.params)
+	}
+}
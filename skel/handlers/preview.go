@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+)
+
+// Path of the preview links, pages can't be saved under it
+const previewPrefix = "/preview"
+
+var errInvalidPreview = errors.New("invalid preview token")
+
+var errNoPreviewSecret = errors.New("pages.preview_secret is not set")
+
+// fallbackSecret signs the preview links in development when pages.preview_secret is not set,
+// they are only valid until the process restarts
+var fallbackSecret struct {
+	once  sync.Once
+	value []byte
+}
+
+// previewSecret returns the configured secret, or the secret of the process in development.
+// Other environments need the secret so the links work on every replica and after restarts.
+func previewSecret(cnf *settings.Config) ([]byte, error) {
+	if cnf.Pages.PreviewSecret != "" {
+		return []byte(cnf.Pages.PreviewSecret), nil
+	}
+	if cnf.Env != settings.Development {
+		return nil, errNoPreviewSecret
+	}
+	fallbackSecret.once.Do(func() {
+		log.Println("WARN: pages.preview_secret is not set, preview links are valid until the restart")
+		raw, err := randomBytes(32)
+		if err != nil {
+			log.Printf("ERR: previewSecret: %s", err)
+		}
+		fallbackSecret.value = raw
+	})
+	return fallbackSecret.value, nil
+}
+
+// signPreview returns the token of a preview link of page id, "<id>.<expiry unix time>.<signature>"
+func signPreview(secret []byte, id uint, expires time.Time) string {
+	payload := fmt.Sprintf("%d.%d", id, expires.Unix())
+	return payload + "." + previewSignature(secret, payload)
+}
+
+// verifyPreview returns the page id of a token signed by secret which has not expired at now
+func verifyPreview(secret []byte, token string, now time.Time) (uint, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, errInvalidPreview
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(previewSignature(secret, payload))) {
+		return 0, errInvalidPreview
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, errInvalidPreview
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, errInvalidPreview
+	}
+	if now.Unix() >= expires {
+		return 0, errors.New("preview token expired")
+	}
+	return uint(id), nil
+}
+
+func previewSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CreatePagePreview handles POST '/api/v2/admin/pages/:id/preview'
+// It returns a signed link showing the page whatever its status, until it expires
+func CreatePagePreview(ctx *gin.Context) {
+	page, ok := findPageParam(ctx)
+	if !ok {
+		return
+	}
+	cnf, err := GetSettings(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	secret, err := previewSecret(cnf)
+	if err != nil {
+		log.Printf("ERR: CreatePagePreview: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expires := time.Now().Add(cnf.Pages.PreviewTTL).Truncate(time.Second)
+	token := signPreview(secret, page.ID, expires)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"url":        previewPrefix + "/" + token,
+		"expires_at": expires.UTC(),
+	})
+}
+
+// PreviewPage handles GET '/preview/:token', the page is rendered in any status
+func PreviewPage(ctx *gin.Context) {
+	cnf, err := GetSettings(ctx)
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	secret, err := previewSecret(cnf)
+	if err != nil {
+		log.Printf("ERR: PreviewPage: %s", err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	id, err := verifyPreview(secret, ctx.Param("token"), time.Now())
+	if err != nil {
+		ctx.String(http.StatusNotFound, err.Error())
+		return
+	}
+	page, err := models.FindPage(id)
+	if err != nil {
+		log.Printf("ERR: PreviewPage: %s", err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	if page == nil {
+		ctx.String(http.StatusNotFound, "page not found")
+		return
+	}
+	// Previews are private, they must not be indexed nor cached
+	ctx.Header("X-Robots-Tag", "noindex")
+	ctx.Header("Cache-Control", "no-store")
+	renderPage(ctx, page, nil)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1600000000, 0)
+	token := signPreview(secret, 42, now.Add(time.Hour))
+	assert.True(t, strings.HasPrefix(token, "42.1600003600."), token)
+
+	id, err := verifyPreview(secret, token, now)
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), id)
+
+	_, err = verifyPreview(secret, token, now.Add(time.Hour))
+	assert.EqualError(t, err, "preview token expired")
+	_, err = verifyPreview([]byte("other"), token, now)
+	assert.Equal(t, errInvalidPreview, err)
+	_, err = verifyPreview(secret, "43"+token[2:], now)
+	assert.Equal(t, errInvalidPreview, err)
+	_, err = verifyPreview(secret, "42", now)
+	assert.Equal(t, errInvalidPreview, err)
+
+	development := &settings.Config{Env: settings.Development}
+	fallback, err := previewSecret(development)
+	require.NoError(t, err)
+	assert.Len(t, fallback, 32)
+	again, err := previewSecret(development)
+	require.NoError(t, err)
+	assert.Equal(t, fallback, again, "the fallback secret lasts as long as the process")
+	_, err = previewSecret(&settings.Config{Env: settings.Production})
+	assert.Equal(t, errNoPreviewSecret, err)
+	configured, err := previewSecret(&settings.Config{Env: settings.Production, Pages: settings.PagesConfig{PreviewSecret: "secret"}})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), configured)
+}
+
+func TestPagePreview(t *testing.T) {
+	initTestDB(t)
+	router := pagesRouter()
+
+	w := request(router, http.MethodPost, "/api/v2/admin/pages", gin.H{"path": "/launch", "title": "Launch", "body": "Coming soon"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = request(router, http.MethodPost, "/api/v2/admin/pages/1/preview", nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	preview := struct {
+		URL       string    `json:"url"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.True(t, strings.HasPrefix(preview.URL, "/preview/1."), preview.URL)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), preview.ExpiresAt, time.Minute)
+
+	w = request(router, http.MethodGet, preview.URL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Coming soon")
+	assert.Equal(t, "noindex", w.Header().Get("X-Robots-Tag"))
+
+	w = request(router, http.MethodGet, preview.URL+"0", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(router, http.MethodPost, "/api/v2/admin/pages/2/preview", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
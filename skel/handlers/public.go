@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/openware/kaigara/pkg/vault"
+	"github.com/openware/pkg/sonic/handlers"
+	"github.com/openware/sonic/skel/events"
+	"github.com/openware/sonic/skel/secrets"
+)
+
+// Component holding the public configuration
+const globalComponent = "global"
+
+// Public config is refreshed on invalidation, resyncInterval is a safety net for lost messages
+const resyncInterval = 5 * time.Minute
+
+// Interval of the keep-alive comments sent on config streams
+const streamKeepAlive = 30 * time.Second
+
+var publicConfig = newConfigCache()
+
+// EventBusMiddleware middleware to set the event bus to gin context
+func EventBusMiddleware(bus events.Bus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("EventBus", bus)
+		c.Next()
+	}
+}
+
+// GetEventBus helper return the event bus from gin context
+func GetEventBus(ctx *gin.Context) (events.Bus, error) {
+	bus, ok := ctx.MustGet("EventBus").(events.Bus)
+	if !ok {
+		return nil, fmt.Errorf("Event bus is not found")
+	}
+	return bus, nil
+}
+
+// RefreshPublicConfig reads the public scope from vault and replaces the cached config
+func RefreshPublicConfig(vaultService *vault.Service) error {
+	secrets.VaultLock.Lock()
+	defer secrets.VaultLock.Unlock()
+
+	if err := vaultService.LoadSecrets(globalComponent, scope); err != nil {
+		return err
+	}
+	data, err := vaultService.GetSecrets(globalComponent, scope)
+	if err != nil {
+		return err
+	}
+
+	changed, err := publicConfig.set(data)
+	if err != nil {
+		return err
+	}
+	if changed {
+		log.Println("Public config cache refreshed")
+	}
+	return nil
+}
+
+// StartConfigInvalidation refreshes the public config as soon as a change is announced on the bus,
+// the subscription is retried until it succeeds
+func StartConfigInvalidation(bus events.Bus, vaultService *vault.Service) {
+	go subscribeConfigInvalidation(bus, func(msg events.ConfigInvalidation) {
+		if msg.Component != globalComponent || msg.Scope != scope {
+			return
+		}
+		if err := RefreshPublicConfig(vaultService); err != nil {
+			log.Printf("ERR: RefreshPublicConfig: %s", err)
+		}
+	})
+
+	for {
+		<-time.After(resyncInterval)
+		if err := RefreshPublicConfig(vaultService); err != nil {
+			log.Printf("ERR: RefreshPublicConfig: %s", err)
+		}
+	}
+}
+
+// subscribeConfigInvalidation subscribes the handler with an exponential backoff, up to resyncInterval
+func subscribeConfigInvalidation(bus events.Bus, handler func(events.ConfigInvalidation)) {
+	delay := time.Second
+	for {
+		_, err := events.SubscribeConfigInvalidation(bus, handler)
+		if err == nil {
+			return
+		}
+		log.Printf("ERR: StartConfigInvalidation: can't subscribe, retrying in %s: %s", delay, err)
+		time.Sleep(delay)
+		if delay *= 2; delay > resyncInterval {
+			delay = resyncInterval
+		}
+	}
+}
+
+// publishInvalidation announces the change of a component scope, failures are only logged
+// since the secret itself was saved
+func publishInvalidation(ctx *gin.Context, component, scope string) {
+	bus, err := GetEventBus(ctx)
+	if err != nil {
+		log.Printf("ERR: publishInvalidation: %s", err)
+		return
+	}
+	if err := events.PublishConfigInvalidation(bus, component, scope); err != nil {
+		log.Printf("ERR: publishInvalidation: %s", err)
+	}
+}
+
+// GetPublicConfigs handles GET '/api/v2/public/config'
+// Clients revalidate their copy with If-None-Match and get a 304 while the config is unchanged
+func GetPublicConfigs(ctx *gin.Context) {
+	body, hash, _ := publicConfig.get()
+	tag := etag(hash)
+
+	ctx.Header("ETag", tag)
+	ctx.Header("Cache-Control", "no-cache")
+	if etagMatch(ctx.GetHeader("If-None-Match"), tag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// StreamPublicConfigs handles GET '/api/v2/public/config/stream'
+// The config is sent as a server-sent event on connection and every time it changes,
+// the event ID is the content hash so reconnecting clients only get newer configs
+func StreamPublicConfigs(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	lastHash := ctx.GetHeader("Last-Event-ID")
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		body, hash, changed := publicConfig.get()
+		if hash != lastHash {
+			ctx.Render(-1, sse.Event{
+				Id:    hash,
+				Event: "config",
+				Data:  string(body),
+			})
+			ctx.Writer.Flush()
+			lastHash = hash
+		}
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			ctx.Writer.WriteString(": keep-alive\n\n")
+			ctx.Writer.Flush()
+		case <-ctx.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// SetSecret handles PUT '/api/v2/admin/:component/secret' and announces the change
+func SetSecret(ctx *gin.Context) {
+	// Peek the scope and restore the body for the sonic handler
+	raw, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(raw))
+
+	var params struct {
+		Scope string `json:"scope"`
+	}
+	json.Unmarshal(raw, &params)
+
+	withVaultLock(handlers.SetSecret)(ctx)
+	if ctx.Writer.Status() == http.StatusOK {
+		publishInvalidation(ctx, ctx.Param("component"), params.Scope)
+	}
+}
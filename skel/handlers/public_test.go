@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestConfigCacheSet(t *testing.T) {
+	cache := newConfigCache()
+	body, hash, changed := cache.get()
+	assert.Equal(t, "{}", string(body))
+
+	updated, err := cache.set(map[string]interface{}{"title": "Sonic", "theme": "dark"})
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	select {
+	case <-changed:
+	default:
+		t.Fatal("waiters were not notified")
+	}
+
+	body, newHash, _ := cache.get()
+	assert.Equal(t, `{"theme":"dark","title":"Sonic"}`, string(body))
+	assert.NotEqual(t, hash, newHash)
+
+	updated, err = cache.set(map[string]interface{}{"theme": "dark", "title": "Sonic"})
+	require.NoError(t, err)
+	assert.False(t, updated)
+}
+
+func TestEtagMatch(t *testing.T) {
+	assert.True(t, etagMatch(`"abc"`, `"abc"`))
+	assert.True(t, etagMatch(`W/"abc"`, `"abc"`))
+	assert.True(t, etagMatch(`"xyz", "abc"`, `"abc"`))
+	assert.True(t, etagMatch(`*`, `"abc"`))
+	assert.False(t, etagMatch(``, `"abc"`))
+	assert.False(t, etagMatch(`"xyz"`, `"abc"`))
+}
+
+func TestGetPublicConfigs(t *testing.T) {
+	publicConfig = newConfigCache()
+	publicConfig.set(map[string]interface{}{"title": "Sonic"})
+
+	router := gin.New()
+	router.GET("/config", GetPublicConfigs)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"title":"Sonic"}`, w.Body.String())
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	tag := w.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	req.Header.Set("If-None-Match", tag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	publicConfig.set(map[string]interface{}{"title": "Opendax"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, tag, w.Header().Get("ETag"))
+}
+
+func TestStreamPublicConfigs(t *testing.T) {
+	publicConfig = newConfigCache()
+	publicConfig.set(map[string]interface{}{"title": "Sonic"})
+
+	router := gin.New()
+	router.GET("/config/stream", StreamPublicConfigs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/config/stream", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(w, req)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	publicConfig.set(map[string]interface{}{"title": "Opendax"})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	body := w.Body.String()
+	assert.Equal(t, 2, strings.Count(body, "event:config"))
+	assert.Contains(t, body, `data:{"title":"Sonic"}`)
+	assert.Contains(t, body, `data:{"title":"Opendax"}`)
+}
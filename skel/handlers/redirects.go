@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+)
+
+// Pagination of the redirect list
+const (
+	defaultRedirectLimit = 50
+	maxRedirectLimit     = 500
+)
+
+type redirectParams struct {
+	Source string `json:"source" binding:"required"`
+	Target string `json:"target"`
+	Status int    `json:"status"`
+}
+
+type redirectEntity struct {
+	ID        uint       `json:"id"`
+	Source    string     `json:"source"`
+	Target    string     `json:"target"`
+	Status    int        `json:"status"`
+	Pattern   bool       `json:"pattern"`
+	Hits      int64      `json:"hits"`
+	LastHitAt *time.Time `json:"last_hit_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func newRedirectEntity(r *models.Redirect) redirectEntity {
+	return redirectEntity{
+		ID:        r.ID,
+		Source:    r.Source,
+		Target:    r.Target,
+		Status:    r.Status,
+		Pattern:   r.Pattern,
+		Hits:      r.Hits,
+		LastHitAt: r.LastHitAt,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+// serveRedirect answers a request matching a redirect and counts it.
+// Paths keep the language prefix of the request, and the query when the target has none.
+func serveRedirect(ctx *gin.Context, redirect *models.Redirect, path, prefix string) {
+	if err := redirect.Hit(time.Now()); err != nil {
+		log.Printf("WARN: serveRedirect: %s", err)
+	}
+	if redirect.Status == http.StatusGone {
+		ctx.Status(http.StatusGone)
+		return
+	}
+	location := redirect.Location(path)
+	if prefix != "" && strings.HasPrefix(location, "/") {
+		location = "/" + prefix + location
+	}
+	if query := ctx.Request.URL.RawQuery; query != "" && !strings.Contains(location, "?") {
+		location += "?" + query
+	}
+	ctx.Redirect(redirect.Status, location)
+}
+
+// redirectRequest serves the redirect of path if there is one and tells if the request was answered
+func redirectRequest(ctx *gin.Context, findRedirect func(path string) (*models.Redirect, error), path, prefix string) bool {
+	redirect, err := findRedirect(path)
+	if err != nil {
+		log.Printf("ERR: notFound: %s", err)
+		ctx.Status(http.StatusInternalServerError)
+		return true
+	}
+	if redirect == nil {
+		return false
+	}
+	serveRedirect(ctx, redirect, path, prefix)
+	return true
+}
+
+// ListRedirects handles GET '/api/v2/admin/redirects'
+// Redirects are listed by source, paginated with page and limit, the Total header has their number
+func ListRedirects(ctx *gin.Context) {
+	page, err := queryInt(ctx, "page", 1, 1, 0)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := queryInt(ctx, "limit", defaultRedirectLimit, 1, maxRedirectLimit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	redirects, total, err := models.ListRedirects((page-1)*limit, limit)
+	if err != nil {
+		log.Printf("ERR: ListRedirects: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entities := make([]redirectEntity, len(redirects))
+	for i := range redirects {
+		entities[i] = newRedirectEntity(&redirects[i])
+	}
+	ctx.Header("Total", strconv.FormatInt(total, 10))
+	ctx.Header("Page", strconv.Itoa(page))
+	ctx.Header("Per-Page", strconv.Itoa(limit))
+	ctx.JSON(http.StatusOK, entities)
+}
+
+// GetRedirect handles GET '/api/v2/admin/redirects/:id'
+func GetRedirect(ctx *gin.Context) {
+	redirect, ok := findRedirectParam(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, newRedirectEntity(redirect))
+}
+
+// CreateRedirect handles POST '/api/v2/admin/redirects'
+// The status is 301 by default, 410 redirects have no target
+func CreateRedirect(ctx *gin.Context) {
+	redirect := &models.Redirect{}
+	if !bindRedirect(ctx, redirect) {
+		return
+	}
+	if saveRedirect(ctx, redirect) {
+		ctx.JSON(http.StatusCreated, newRedirectEntity(redirect))
+	}
+}
+
+// UpdateRedirect handles PUT '/api/v2/admin/redirects/:id'
+func UpdateRedirect(ctx *gin.Context) {
+	redirect, ok := findRedirectParam(ctx)
+	if !ok || !bindRedirect(ctx, redirect) {
+		return
+	}
+	if saveRedirect(ctx, redirect) {
+		ctx.JSON(http.StatusOK, newRedirectEntity(redirect))
+	}
+}
+
+// DeleteRedirect handles DELETE '/api/v2/admin/redirects/:id'
+func DeleteRedirect(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	found, err := models.DeleteRedirect(uint(id))
+	if err != nil {
+		log.Printf("ERR: DeleteRedirect: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "redirect not found"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func findRedirectParam(ctx *gin.Context) (*models.Redirect, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+	redirect, err := models.GetRedirect(uint(id))
+	if err != nil {
+		log.Printf("ERR: GetRedirect: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if redirect == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "redirect not found"})
+		return nil, false
+	}
+	return redirect, true
+}
+
+// bindRedirect reads the request into the redirect, sources served by routes are rejected since they'd never match
+func bindRedirect(ctx *gin.Context, redirect *models.Redirect) bool {
+	params := redirectParams{}
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	redirect.Source = params.Source
+	redirect.Target = params.Target
+	redirect.Status = params.Status
+	if redirect.Status == 0 {
+		redirect.Status = http.StatusMovedPermanently
+	}
+
+	err := redirect.Validate()
+	if err == nil && reservedPath(ctx, strings.TrimSuffix(redirect.Source, "*")) {
+		err = fmt.Errorf("source %s is reserved", redirect.Source)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func saveRedirect(ctx *gin.Context, redirect *models.Redirect) bool {
+	err := models.SaveRedirect(redirect)
+	if err == models.ErrRedirectTaken {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return false
+	}
+	if errors.Is(err, models.ErrRedirectChain) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		log.Printf("ERR: SaveRedirect: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
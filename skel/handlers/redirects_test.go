@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func redirectsRouter() *gin.Engine {
+	reloader := settings.NewReloader(&settings.Config{
+		Routes: settings.RoutesConfig{API: []string{"/api"}},
+	}, nil, nil)
+	router := gin.New()
+	admin := router.Group("/api/v2/admin", SettingsMiddleware(reloader))
+	admin.GET("/redirects", ListRedirects)
+	admin.GET("/redirects/:id", GetRedirect)
+	admin.POST("/redirects", CreateRedirect)
+	admin.PUT("/redirects/:id", UpdateRedirect)
+	admin.DELETE("/redirects/:id", DeleteRedirect)
+	return router
+}
+
+func TestRedirectsAPI(t *testing.T) {
+	initTestDB(t)
+	router := redirectsRouter()
+
+	w := request(router, http.MethodPost, "/api/v2/admin/redirects", gin.H{"source": "/fees", "target": "/pricing"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Regexp(t, `^\{"id":1,"source":"/fees","target":"/pricing","status":301,"pattern":false,"hits":0,"last_hit_at":null,`, w.Body.String())
+
+	w = request(router, http.MethodPost, "/api/v2/admin/redirects", gin.H{"source": "/fees", "target": "/markets"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = request(router, http.MethodPost, "/api/v2/admin/redirects", gin.H{"source": "/listing", "target": "/markets", "status": 410})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"error":"gone redirects have no target"}`, w.Body.String())
+	w = request(router, http.MethodPost, "/api/v2/admin/redirects", gin.H{"source": "/api/v2/*", "target": "/api/v3/*"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = request(router, http.MethodPost, "/api/v2/admin/redirects", gin.H{"source": "/pricing", "target": "/fees"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"error":"target is redirected by /fees"}`, w.Body.String())
+	w = request(router, http.MethodPost, "/api/v2/admin/redirects", gin.H{"target": "/markets"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(router, http.MethodPost, "/api/v2/admin/redirects", gin.H{"source": "/blog/*", "target": "/news/*", "status": 302})
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":302,"pattern":true`)
+
+	w = request(router, http.MethodPut, "/api/v2/admin/redirects/1", gin.H{"source": "/fees", "status": 410})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/redirects/1", nil)
+	assert.Contains(t, w.Body.String(), `"source":"/fees","target":"","status":410`)
+
+	w = request(router, http.MethodGet, "/api/v2/admin/redirects?limit=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Total"))
+	assert.Regexp(t, `^\[\{"id":2,"source":"/blog/\*"`, w.Body.String())
+
+	w = request(router, http.MethodDelete, "/api/v2/admin/redirects/1", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/redirects/1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(router, http.MethodDelete, "/api/v2/admin/redirects/1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServeRedirect(t *testing.T) {
+	initTestDB(t)
+	require.NoError(t, models.SavePage(&models.Page{Path: "/fees", Title: "Fees"}, ""))
+	require.NoError(t, models.SaveRedirect(&models.Redirect{Source: "/fees", Target: "/pricing"}))
+	require.NoError(t, models.SaveRedirect(&models.Redirect{Source: "/blog/*", Target: "/news/*", Status: http.StatusFound}))
+	require.NoError(t, models.SaveRedirect(&models.Redirect{Source: "/status", Target: "https://status.openware.com"}))
+	require.NoError(t, models.SaveRedirect(&models.Redirect{Source: "/listing", Status: http.StatusGone}))
+	require.NoError(t, models.SaveRedirect(&models.Redirect{Source: "/about.php", Target: "/about"}))
+	require.NoError(t, models.SaveRedirect(&models.Redirect{Source: "/old/*", Target: "/*"}))
+	router := pagesRouter()
+	router.NoRoute(notFound(settings.NewReloader(&settings.Config{
+		Languages: settings.LanguagesConfig{Default: "en", Supported: []string{"en", "ru"}},
+	}, nil, nil), models.FindPageByPath, models.FindRedirect))
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/fees", http.StatusOK, ""},
+		{"/blog/2021/fees?utm=mail", http.StatusFound, "/news/2021/fees?utm=mail"},
+		{"/ru/blog/fees", http.StatusFound, "/ru/news/fees"},
+		{"/ru/status", http.StatusMovedPermanently, "https://status.openware.com"},
+		{"/listing", http.StatusGone, ""},
+		{"/about.php?id=1", http.StatusMovedPermanently, "/about?id=1"},
+		{"/ru/about.php", http.StatusMovedPermanently, "/ru/about"},
+		{"/old/fees", http.StatusMovedPermanently, "/fees"},
+		{"/old//evil.example/x", http.StatusMovedPermanently, "/evil.example/x"},
+		{"/old/%5C%5Cevil.example", http.StatusMovedPermanently, "/evil.example"},
+		{"/old/%09/evil.example", http.StatusMovedPermanently, "/evil.example"},
+		{"/terms.pdf", http.StatusNotFound, ""},
+		{"/blog", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Equal(t, tt.status, w.Code, tt.path)
+		assert.Equal(t, tt.location, w.Header().Get("Location"), tt.path)
+	}
+
+	redirect, err := models.FindRedirect("/blog/fees")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), redirect.Hits)
+	redirect, err = models.FindRedirect("/fees")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), redirect.Hits)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/pkg/jwt"
+	"github.com/openware/sonic/skel/content"
+	"github.com/openware/sonic/skel/models"
+)
+
+// revisionEntity is the page revision returned by the admin API
+type revisionEntity struct {
+	Version       uint       `json:"version"`
+	Author        string     `json:"author"`
+	Path          string     `json:"path"`
+	Lang          string     `json:"lang"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Body          string     `json:"body,omitempty"`
+	Keywords      string     `json:"keywords"`
+	CanonicalURL  string     `json:"canonical_url"`
+	OGTitle       string     `json:"og_title"`
+	OGDescription string     `json:"og_description"`
+	OGImage       string     `json:"og_image"`
+	TwitterCard   string     `json:"twitter_card"`
+	NoIndex       bool       `json:"noindex"`
+	Format        string     `json:"format"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at"`
+	UnpublishAt   *time.Time `json:"unpublish_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func newRevisionEntity(r *models.PageRevision) revisionEntity {
+	return revisionEntity{
+		Version:       r.Version,
+		Author:        r.Author,
+		Path:          r.Path,
+		Lang:          r.Lang,
+		Title:         r.Title,
+		Description:   r.Description,
+		Body:          r.Body,
+		Keywords:      r.Keywords,
+		CanonicalURL:  r.CanonicalURL,
+		OGTitle:       r.OGTitle,
+		OGDescription: r.OGDescription,
+		OGImage:       r.OGImage,
+		TwitterCard:   r.TwitterCard,
+		NoIndex:       r.NoIndex,
+		Format:        r.Format,
+		Status:        r.Status,
+		PublishAt:     r.PublishAt,
+		UnpublishAt:   r.UnpublishAt,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// ListPageRevisions handles GET '/api/v2/admin/pages/:id/revisions'
+// Revisions are returned without their body, the latest first
+func ListPageRevisions(ctx *gin.Context) {
+	page, ok := findPageParam(ctx)
+	if !ok {
+		return
+	}
+	revisions, err := models.ListPageRevisions(page.ID)
+	if err != nil {
+		log.Printf("ERR: ListPageRevisions: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	entities := make([]revisionEntity, len(revisions))
+	for i := range revisions {
+		entities[i] = newRevisionEntity(&revisions[i])
+		entities[i].Body = ""
+	}
+	ctx.JSON(http.StatusOK, entities)
+}
+
+// GetPageRevision handles GET '/api/v2/admin/pages/:id/revisions/:version'
+func GetPageRevision(ctx *gin.Context) {
+	page, ok := findPageParam(ctx)
+	if !ok {
+		return
+	}
+	revision, ok := findRevision(ctx, page.ID, ctx.Param("version"))
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, newRevisionEntity(revision))
+}
+
+// GetPageDiff handles GET '/api/v2/admin/pages/:id/diff?from=&to='
+// It returns the unified diff of the two revisions, 'to' defaults to the latest one
+func GetPageDiff(ctx *gin.Context) {
+	page, ok := findPageParam(ctx)
+	if !ok {
+		return
+	}
+	from, ok := findRevision(ctx, page.ID, ctx.Query("from"))
+	if !ok {
+		return
+	}
+
+	var to *models.PageRevision
+	if raw := ctx.Query("to"); raw != "" {
+		if to, ok = findRevision(ctx, page.ID, raw); !ok {
+			return
+		}
+	} else {
+		revisions, err := models.ListPageRevisions(page.ID)
+		if err != nil {
+			log.Printf("ERR: ListPageRevisions: %s", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		to = &revisions[0]
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"from": from.Version,
+		"to":   to.Version,
+		"diff": content.Diff(revisionName(from), revisionName(to), from.Text(), to.Text()),
+	})
+}
+
+// RestorePageRevision handles POST '/api/v2/admin/pages/:id/revisions/:version/restore'
+// The content of the revision is saved as a new revision, the status of the page is kept
+func RestorePageRevision(ctx *gin.Context) {
+	page, ok := findPageParam(ctx)
+	if !ok {
+		return
+	}
+	revision, ok := findRevision(ctx, page.ID, ctx.Param("version"))
+	if !ok {
+		return
+	}
+	revision.Restore(page)
+	err := page.Validate()
+	if err == nil && reservedPath(ctx, page.Path) {
+		err = fmt.Errorf("path %s is reserved", page.Path)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if savePage(ctx, page) {
+		log.Printf("Restored page %d to version %d as version %d", page.ID, revision.Version, page.Version)
+		ctx.JSON(http.StatusOK, newPageEntity(page))
+	}
+}
+
+// findRevision returns the revision of the page with the version given as string,
+// the error response is sent when there is none
+func findRevision(ctx *gin.Context, pageID uint, raw string) (*models.PageRevision, bool) {
+	version, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil || version == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return nil, false
+	}
+	revision, err := models.FindPageRevision(pageID, uint(version))
+	if err != nil {
+		log.Printf("ERR: FindPageRevision: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if revision == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+		return nil, false
+	}
+	return revision, true
+}
+
+func revisionName(r *models.PageRevision) string {
+	return fmt.Sprintf("version %d, %s by %s", r.Version, r.CreatedAt.UTC().Format(time.RFC3339), r.Author)
+}
+
+// author returns the email, or the UID, of the authenticated user
+func author(ctx *gin.Context) string {
+	value, ok := ctx.Get("auth")
+	if !ok {
+		return ""
+	}
+	auth, ok := value.(*jwt.Auth)
+	if !ok {
+		return ""
+	}
+	if auth.Email != "" {
+		return auth.Email
+	}
+	return auth.UID
+}
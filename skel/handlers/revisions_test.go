@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/pkg/jwt"
+	"github.com/openware/sonic/skel/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageRevisions(t *testing.T) {
+	initTestDB(t)
+	router := pagesRouter()
+
+	w := request(router, http.MethodPost, "/api/v2/admin/pages", gin.H{"path": "/terms", "title": "Terms", "body": "# Terms\n\nBe nice."})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = request(router, http.MethodPut, "/api/v2/admin/pages/1", gin.H{"path": "/terms", "title": "Terms", "body": "# Terms\n\nBe rude."})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/1/revisions", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	revisions := []revisionEntity{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+	require.Len(t, revisions, 2)
+	assert.Equal(t, uint(2), revisions[0].Version)
+	assert.Equal(t, uint(1), revisions[1].Version)
+	assert.Empty(t, revisions[0].Body)
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/1/revisions/1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Be nice.")
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/1/diff?from=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	diff := struct {
+		From uint   `json:"from"`
+		To   uint   `json:"to"`
+		Diff string `json:"diff"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.Equal(t, uint(2), diff.To)
+	assert.Contains(t, diff.Diff, "-Be nice.\n+Be rude.\n")
+
+	w = request(router, http.MethodPost, "/api/v2/admin/pages/1/revisions/1/restore", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	restored := pageEntity{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+	assert.Equal(t, uint(3), restored.Version)
+	assert.Equal(t, "# Terms\n\nBe nice.", restored.Body)
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/1/diff?from=1&to=3", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.Empty(t, diff.Diff)
+
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/1/revisions/9", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(router, http.MethodGet, "/api/v2/admin/pages/1/diff?from=x", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(router, http.MethodDelete, "/api/v2/admin/pages/1", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = request(router, http.MethodPost, "/api/v2/admin/pages", gin.H{"path": "/terms", "title": "Terms"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+	w = request(router, http.MethodGet, fmt.Sprintf("/api/v2/admin/pages/%d/revisions", restored.ID), nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+	assert.Len(t, revisions, 1)
+}
+
+func TestRestoreReservedPath(t *testing.T) {
+	initTestDB(t)
+	router := pagesRouter()
+
+	// Written before the path was reserved
+	require.NoError(t, models.SavePage(&models.Page{Path: "/api/terms", Title: "Terms"}, ""))
+	w := request(router, http.MethodPut, "/api/v2/admin/pages/1", gin.H{"path": "/terms", "title": "Terms"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = request(router, http.MethodPost, "/api/v2/admin/pages/1/revisions/1/restore", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"error":"path /api/terms is reserved"}`, w.Body.String())
+	page, err := models.FindPage(1)
+	require.NoError(t, err)
+	assert.Equal(t, "/terms", page.Path)
+}
+
+func TestAuthor(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(nil)
+	assert.Equal(t, "", author(ctx))
+	ctx.Set("auth", &jwt.Auth{UID: "ID123"})
+	assert.Equal(t, "ID123", author(ctx))
+	ctx.Set("auth", &jwt.Auth{UID: "ID123", Email: "admin@example.com"})
+	assert.Equal(t, "admin@example.com", author(ctx))
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/content"
+	"github.com/openware/sonic/skel/models"
+)
+
+// Number of results of a search, by default and at most
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+// snippetWords is the length of the passage of the body returned with each result
+const snippetWords = 30
+
+// searchResult is a page matching a search, TitleHTML and Snippet are escaped HTML with the matched words in <mark>
+type searchResult struct {
+	Path        string `json:"path"`
+	Lang        string `json:"lang"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	TitleHTML   string `json:"title_html"`
+	Snippet     string `json:"snippet"`
+}
+
+// SearchPages handles GET '/api/v2/public/pages/search?q='
+// It returns the live pages containing words starting with every word of q, best matches first
+func SearchPages(ctx *gin.Context) {
+	terms := content.Terms(ctx.Query("q"))
+	if len(terms) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	limit, err := queryInt(ctx, "limit", defaultSearchLimit, 1, maxSearchLimit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pages, err := models.SearchPages(terms, ctx.Query("lang"), limit)
+	if err != nil {
+		log.Printf("ERR: SearchPages: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]searchResult, len(pages))
+	for i, page := range pages {
+		text := content.Text(page.Format, page.Body)
+		if text == "" {
+			text = page.Description
+		}
+		results[i] = searchResult{
+			Path:        page.Path,
+			Lang:        page.Lang,
+			Title:       page.Title,
+			Description: page.Description,
+			TitleHTML:   string(content.Highlight(page.Title, terms)),
+			Snippet:     string(content.Snippet(text, terms, snippetWords)),
+		}
+	}
+	ctx.JSON(http.StatusOK, results)
+}
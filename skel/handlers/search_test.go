@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchPages(t *testing.T) {
+	initTestDB(t)
+	require.NoError(t, models.SavePage(&models.Page{Path: "/fees", Lang: "en", Title: "Trading fees", Description: "Our fees", Body: "# Fees\n\nMaker <fees> are **low**."}, ""))
+	require.NoError(t, models.SavePage(&models.Page{Path: "/faq", Title: "FAQ", Body: "Nothing to see"}, ""))
+	router := gin.New()
+	router.GET("/api/v2/public/pages/search", SearchPages)
+
+	w := request(router, http.MethodGet, "/api/v2/public/pages/search?q=fee", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	results := []searchResult{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	assert.Equal(t, []searchResult{{
+		Path:        "/fees",
+		Lang:        "en",
+		Title:       "Trading fees",
+		Description: "Our fees",
+		TitleHTML:   "Trading <mark>fees</mark>",
+		Snippet:     "<mark>Fees</mark> Maker &lt;<mark>fees</mark>&gt; are low.",
+	}}, results)
+
+	w = request(router, http.MethodGet, "/api/v2/public/pages/search?q=withdraw&lang=en", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+
+	w = request(router, http.MethodGet, "/api/v2/public/pages/search?q=+-+", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"error":"q is required"}`, w.Body.String())
+	w = request(router, http.MethodGet, "/api/v2/public/pages/search?q=fee&limit=100", nil)
+	assert.Equal(t, `{"error":"invalid limit, expected 1 to 50"}`, w.Body.String())
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/secrets"
+)
+
+var secretScopes = []string{"public", "private", "secret"}
+
+type rollbackSecretParams struct {
+	Scope   string `json:"scope" binding:"required"`
+	Version int64  `json:"version" binding:"required"`
+}
+
+// SecretStoreMiddleware middleware to set the secret versions store to gin context
+func SecretStoreMiddleware(store *secrets.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("SecretStore", store)
+		c.Next()
+	}
+}
+
+// GetSecretStore helper return the secret versions store from gin context
+func GetSecretStore(ctx *gin.Context) (*secrets.Store, error) {
+	store, ok := ctx.MustGet("SecretStore").(*secrets.Store)
+	if !ok {
+		return nil, fmt.Errorf("Secret store is not found")
+	}
+	return store, nil
+}
+
+// withVaultLock runs a handler of the sonic package holding secrets.VaultLock,
+// they use the vault.Service shared with the config refresh and the daemons
+func withVaultLock(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		secrets.VaultLock.Lock()
+		defer secrets.VaultLock.Unlock()
+		handler(ctx)
+	}
+}
+
+func validScope(scope string) bool {
+	for _, s := range secretScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSecretVersions handles GET '/api/v2/admin/:component/secret/versions?scope='
+func GetSecretVersions(ctx *gin.Context) {
+	store, err := GetSecretStore(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	scope := ctx.Query("scope")
+	if !validScope(scope) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid scope"})
+		return
+	}
+
+	versions, err := store.ListVersions(ctx.Param("component"), scope)
+	if err != nil {
+		log.Printf("ERR: ListVersions: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, versions)
+}
+
+// GetSecretDiff handles GET '/api/v2/admin/:component/secret/diff?scope=&from=&to='
+// 'to' defaults to the latest version
+func GetSecretDiff(ctx *gin.Context) {
+	store, err := GetSecretStore(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	scope := ctx.Query("scope")
+	if !validScope(scope) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid scope"})
+		return
+	}
+
+	from, err := strconv.ParseInt(ctx.Query("from"), 10, 64)
+	if err != nil || from <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid from version"})
+		return
+	}
+
+	var to int64
+	if raw := ctx.Query("to"); raw != "" {
+		to, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || to <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid to version"})
+			return
+		}
+	}
+
+	changes, err := store.Diff(ctx.Param("component"), scope, from, to)
+	if err != nil {
+		log.Printf("ERR: Diff: %s", err)
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, changes)
+}
+
+// RollbackSecret handles POST '/api/v2/admin/:component/secret/rollback'
+func RollbackSecret(ctx *gin.Context) {
+	store, err := GetSecretStore(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var params rollbackSecretParams
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validScope(params.Scope) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid scope"})
+		return
+	}
+
+	appName := ctx.Param("component")
+	version, err := store.Rollback(appName, params.Scope, params.Version)
+	if err != nil {
+		log.Printf("ERR: Rollback: %s", err)
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Rolled back %s.%s to version %d as version %d", appName, params.Scope, params.Version, version)
+	publishInvalidation(ctx, appName, params.Scope)
+
+	ctx.JSON(http.StatusOK, gin.H{"version": version})
+}
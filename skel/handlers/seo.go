@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+)
+
+// Namespaces of the sitemap and of its alternate links
+const (
+	sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	xhtmlNamespace   = "http://www.w3.org/1999/xhtml"
+)
+
+// pageMeta is the SEO metadata rendered in the head of page.html
+type pageMeta struct {
+	Keywords      string
+	Canonical     string
+	OGTitle       string
+	OGDescription string
+	OGImage       string
+	TwitterCard   string
+	NoIndex       bool
+}
+
+// newPageMeta returns the metadata of a page, Open Graph and Twitter fields default to the title and description.
+// multilingual pages have their language in their URL.
+func newPageMeta(ctx *gin.Context, page *models.Page, multilingual bool) pageMeta {
+	meta := pageMeta{
+		Keywords:      page.Keywords,
+		Canonical:     pageURL(ctx, page, multilingual),
+		OGTitle:       page.OGTitle,
+		OGDescription: page.OGDescription,
+		TwitterCard:   page.TwitterCard,
+		NoIndex:       page.NoIndex,
+	}
+	if meta.OGTitle == "" {
+		meta.OGTitle = page.Title
+	}
+	if meta.OGDescription == "" {
+		meta.OGDescription = page.Description
+	}
+	if page.OGImage != "" {
+		meta.OGImage = absoluteURL(ctx, page.OGImage)
+	}
+	if meta.TwitterCard == "" {
+		meta.TwitterCard = "summary"
+		if meta.OGImage != "" {
+			meta.TwitterCard = "summary_large_image"
+		}
+	}
+	return meta
+}
+
+// pageURL returns the canonical URL of a page, its canonical_url or its path, prefixed by its language when multilingual
+func pageURL(ctx *gin.Context, page *models.Page, multilingual bool) string {
+	if page.CanonicalURL != "" {
+		return absoluteURL(ctx, page.CanonicalURL)
+	}
+	if multilingual && page.Lang != "" {
+		return absoluteURL(ctx, "/"+page.Lang+page.Path)
+	}
+	return absoluteURL(ctx, page.Path)
+}
+
+// absoluteURL returns the URL of a path on seo.base_url, or on the host of the request when it's not set.
+// Behind a proxy the scheme is read from X-Forwarded-Proto. URLs are returned as is.
+func absoluteURL(ctx *gin.Context, p string) string {
+	if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") {
+		return p
+	}
+	if cnf, ok := ctx.Value("Settings").(*settings.Config); ok && cnf.SEO.BaseURL != "" {
+		return strings.TrimSuffix(cnf.SEO.BaseURL, "/") + p
+	}
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := ctx.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + ctx.Request.Host + p
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	XHTML   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string        `xml:"loc"`
+	LastMod    string        `xml:"lastmod"`
+	Alternates []sitemapLink `xml:"xhtml:link"`
+}
+
+type sitemapLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// Sitemap handles GET '/sitemap.xml'
+// It lists the canonical URL of the live pages without noindex, translations link each other
+func Sitemap(ctx *gin.Context) {
+	pages, err := models.ListLivePages()
+	if err != nil {
+		log.Printf("ERR: Sitemap: %s", err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	// Languages of each path, the same links as the alternates of the pages
+	languages := map[string][]string{}
+	for _, page := range pages {
+		if !page.NoIndex {
+			languages[page.Path] = append(languages[page.Path], page.Lang)
+		}
+	}
+
+	set := sitemapURLSet{XMLNS: sitemapNamespace, XHTML: xhtmlNamespace, URLs: []sitemapURL{}}
+	for i := range pages {
+		page := &pages[i]
+		if page.NoIndex {
+			continue
+		}
+		alternates := alternateLinks(ctx, page.Path, languages[page.Path])
+		entry := sitemapURL{
+			Loc:     pageURL(ctx, page, alternates != nil),
+			LastMod: page.UpdatedAt.UTC().Format("2006-01-02"),
+		}
+		for _, alternate := range alternates {
+			entry.Alternates = append(entry.Alternates, sitemapLink{Rel: "alternate", Hreflang: alternate.Lang, Href: alternate.URL})
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+
+	raw, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		log.Printf("ERR: Sitemap: %s", err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age=3600")
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), raw...))
+}
+
+// robots handles GET '/robots.txt' with seo.robots or public/robots.txt, followed by the sitemap URL
+func robots(fsys fs.FS) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		text := ""
+		if cnf, err := GetSettings(ctx); err == nil {
+			text = cnf.SEO.Robots
+		}
+		if text == "" {
+			raw, err := fs.ReadFile(fsys, "public/robots.txt")
+			if err != nil {
+				log.Printf("WARN: robots: %s", err)
+			}
+			text = string(raw)
+		}
+		if !strings.Contains(strings.ToLower(text), "sitemap:") {
+			if text != "" && !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			text += "Sitemap: " + absoluteURL(ctx, "/sitemap.xml") + "\n"
+		}
+		ctx.Header("Cache-Control", "public, max-age=3600")
+		ctx.String(http.StatusOK, text)
+	}
+}
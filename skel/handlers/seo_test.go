@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/models"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seoRouter(cnf *settings.Config) *gin.Engine {
+	reloader := settings.NewReloader(cnf, nil, nil)
+	router := gin.New()
+	router.GET("/sitemap.xml", SettingsMiddleware(reloader), Sitemap)
+	router.GET("/robots.txt", SettingsMiddleware(reloader), robots(fstest.MapFS{
+		"public/robots.txt": {Data: []byte("User-agent: *\nDisallow:")},
+	}))
+	return router
+}
+
+func TestSitemap(t *testing.T) {
+	initTestDB(t)
+	for _, page := range []models.Page{
+		{Path: "/terms", Lang: "en", Title: "Terms"},
+		{Path: "/terms", Lang: "ru", Title: "Условия"},
+		{Path: "/fees", Title: "Fees", CanonicalURL: "https://exchange.io/pricing"},
+		{Path: "/hidden", Title: "Hidden", NoIndex: true},
+		{Path: "/draft", Title: "Draft", Status: models.PageDraft},
+	} {
+		require.NoError(t, models.SavePage(&page, ""))
+	}
+
+	router := seoRouter(&settings.Config{SEO: settings.SEOConfig{BaseURL: "https://exchange.io/"}})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">`)
+	assert.Contains(t, body, "<loc>https://exchange.io/pricing</loc>")
+	assert.Contains(t, body, "<loc>https://exchange.io/en/terms</loc>")
+	assert.Contains(t, body, "<loc>https://exchange.io/ru/terms</loc>")
+	assert.Contains(t, body, `<xhtml:link rel="alternate" hreflang="ru" href="https://exchange.io/ru/terms"></xhtml:link>`)
+	assert.Contains(t, body, `<xhtml:link rel="alternate" hreflang="x-default" href="https://exchange.io/terms"></xhtml:link>`)
+	assert.NotContains(t, body, "hidden")
+	assert.NotContains(t, body, "draft")
+}
+
+func TestRobots(t *testing.T) {
+	w := httptest.NewRecorder()
+	seoRouter(&settings.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "User-agent: *\nDisallow:\nSitemap: http://example.com/sitemap.xml\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	seoRouter(&settings.Config{SEO: settings.SEOConfig{
+		BaseURL: "https://exchange.io",
+		Robots:  "User-agent: *\nDisallow: /api/\nSitemap: https://cdn.exchange.io/sitemap.xml\n",
+	}}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	assert.Equal(t, "User-agent: *\nDisallow: /api/\nSitemap: https://cdn.exchange.io/sitemap.xml\n", w.Body.String())
+}
+
+func TestPageMeta(t *testing.T) {
+	initTestDB(t)
+	require.NoError(t, models.SavePage(&models.Page{Path: "/fees", Title: "Fees", Description: "Trading fees", Keywords: "fees, trading", OGImage: "/public/fees.png", NoIndex: true}, ""))
+	router := pagesRouter()
+	router.NoRoute(notFound(settings.NewReloader(&settings.Config{}, nil, nil), models.FindPageByPath, models.FindRedirect))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fees", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "noindex", w.Header().Get("X-Robots-Tag"))
+	body := w.Body.String()
+	assert.Contains(t, body, `<meta name="keywords" content="fees, trading">`)
+	assert.Contains(t, body, `<meta name="robots" content="noindex">`)
+	assert.Contains(t, body, `<link rel="canonical" href="http://example.com/fees">`)
+	assert.Contains(t, body, `<meta property="og:title" content="Fees">`)
+	assert.Contains(t, body, `<meta property="og:description" content="Trading fees">`)
+	assert.Contains(t, body, `<meta property="og:image" content="http://example.com/public/fees.png">`)
+	assert.Contains(t, body, `<meta name="twitter:card" content="summary_large_image">`)
+}
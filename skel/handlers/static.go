@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/assets"
+	"github.com/openware/sonic/skel/settings"
+)
+
+// Cache-Control of the static files
+const (
+	immutableCache  = "public, max-age=31536000, immutable"
+	revalidateCache = "no-cache"
+)
+
+// publicPrefix is the path the public directory is served at
+const publicPrefix = "/public"
+
+// Files of the frontend build loaded by the index page
+var bundle = &assets.Bundle{}
+
+// loadBundle reads the asset manifest from fsys, or lists the built files when there is none
+func loadBundle(fsys fs.FS, cnf settings.AssetConfig) *assets.Bundle {
+	dir := filepath.ToSlash(filepath.Clean(cnf.Output))
+	b, err := assets.LoadBundle(fsys, dir, "/"+dir, cnf.Entries)
+	if err == nil {
+		return b
+	}
+	log.Printf("WARN: asset manifest is not loaded, using the files of %s: %s", cnf.Output, err.Error())
+
+	b, err = assets.GlobBundle(fsys, dir, "/"+dir)
+	if err != nil {
+		log.Printf("ERR: loadBundle: %s", err)
+		return &assets.Bundle{}
+	}
+	return b
+}
+
+// filesOnly serves the files of a file system without listing directories
+type filesOnly struct {
+	fs http.FileSystem
+}
+
+func (f filesOnly) Open(name string) (http.File, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil, os.ErrNotExist
+	}
+	return file, nil
+}
+
+// publicFiles returns the public directory of fsys
+func publicFiles(fsys fs.FS) (http.FileSystem, error) {
+	public, err := fs.Sub(fsys, "public")
+	if err != nil {
+		return nil, err
+	}
+	return filesOnly{http.FS(public)}, nil
+}
+
+// StaticCacheMiddleware lets browsers keep fingerprinted files forever,
+// the other ones are revalidated on every use
+func StaticCacheMiddleware(b *assets.Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if b.Immutable(c.Request.URL.Path) {
+			c.Header("Cache-Control", immutableCache)
+		} else {
+			c.Header("Cache-Control", revalidateCache)
+		}
+		c.Next()
+	}
+}
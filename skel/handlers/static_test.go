@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openware/sonic/skel/assets"
+	"github.com/openware/sonic/skel/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticCacheMiddleware(t *testing.T) {
+	root, err := ioutil.TempDir("", "static")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "public")
+	output := filepath.Join(dir, "assets")
+	require.NoError(t, os.MkdirAll(output, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(output, "main.js"), []byte("main"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "robots.txt"), []byte("robots"), 0644))
+	manifest, err := assets.Fingerprint(output)
+	require.NoError(t, err)
+
+	b, err := assets.LoadBundle(os.DirFS(output), ".", "/public/assets", nil)
+	require.NoError(t, err)
+	public, err := publicFiles(os.DirFS(root))
+	require.NoError(t, err)
+	router := gin.New()
+	router.Group("/public", StaticCacheMiddleware(b)).StaticFS("/", public)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/assets/"+manifest["main.js"].File, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, immutableCache, w.Header().Get("Cache-Control"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/robots.txt", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, revalidateCache, w.Header().Get("Cache-Control"))
+
+	// Directories are not listed
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/assets/", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLoadBundleFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "assets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.js"), []byte("main"), 0644))
+
+	b := loadBundle(os.DirFS(dir), settings.AssetConfig{Output: "."})
+	assert.Equal(t, []string{"/main.js"}, b.Scripts)
+}
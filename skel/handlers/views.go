@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/foolin/goview"
+	"github.com/foolin/goview/supports/ginview"
+)
+
+// newViewEngine reads the templates from the views directory of fsys, with the functions of templateFuncs.
+// Templates are parsed on every render when live is set, so changes show up without restart.
+func newViewEngine(fsys fs.FS, live bool) *ginview.ViewEngine {
+	cnf := goview.DefaultConfig
+	cnf.DisableCache = live
+	cnf.Funcs = templateFuncs()
+
+	engine := ginview.New(cnf)
+	engine.SetFileHandler(func(config goview.Config, tplFile string) (string, error) {
+		name := path.Join(config.Root, tplFile+config.Extension)
+		raw, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return "", fmt.Errorf("ViewEngine render read name:%v, path:%v, error: %v", tplFile, name, err)
+		}
+		return string(raw), nil
+	})
+	return engine
+}
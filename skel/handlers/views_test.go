@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewEngine(t *testing.T) {
+	fsys := fstest.MapFS{
+		"views/layouts/master.html": {Data: []byte(`<main>{{template "content" .}}</main>`)},
+		"views/hello.html":          {Data: []byte(`{{define "content"}}Hello {{.name}}{{end}}`)},
+	}
+	router := gin.New()
+	router.HTMLRender = newViewEngine(fsys, true)
+	router.GET("/", func(ctx *gin.Context) {
+		ctx.HTML(http.StatusOK, "hello", gin.H{"name": "sonic"})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<main>Hello sonic</main>", w.Body.String())
+
+	// Templates are read again when live
+	fsys["views/hello.html"].Data = []byte(`{{define "content"}}Bye {{.name}}{{end}}`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "<main>Bye sonic</main>", w.Body.String())
+}
@@ -0,0 +1,280 @@
+// Package i18n translates the texts of the views with catalogs of messages by locale
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CountArg is the argument selecting the plural form of a message
+const CountArg = "count"
+
+// Message is the text of a key, plural messages have a text by plural category instead
+type Message struct {
+	Text   string
+	Plural map[string]string
+}
+
+// MarshalJSON writes texts as strings and plural messages as objects by category
+func (m Message) MarshalJSON() ([]byte, error) {
+	if m.Plural != nil {
+		return json.Marshal(m.Plural)
+	}
+	return json.Marshal(m.Text)
+}
+
+// Catalogs holds the messages of every locale, keys are dotted paths like "home.title"
+type Catalogs struct {
+	// Default is the locale of the messages missing in the other ones
+	Default string
+	locales map[string]map[string]Message
+}
+
+// New creates catalogs from the messages of each locale
+func New(def string, locales map[string]map[string]Message) *Catalogs {
+	normalized := make(map[string]map[string]Message, len(locales))
+	for locale, messages := range locales {
+		normalized[normalize(locale)] = messages
+	}
+	return &Catalogs{Default: normalize(def), locales: normalized}
+}
+
+// Load reads the catalogs of dir, one file by locale named after it: en.yml, ru.yaml or pt-br.json.
+// Nested keys are joined with dots, and maps of plural categories with an "other" text are plural messages.
+func Load(fsys fs.FS, dir, def string) (*Catalogs, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	locales := map[string]map[string]Message{}
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml" && ext != ".json") {
+			continue
+		}
+		raw, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var tree interface{}
+		if ext == ".json" {
+			err = json.Unmarshal(raw, &tree)
+		} else {
+			err = yaml.Unmarshal(raw, &tree)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		messages := map[string]Message{}
+		if err := flatten(messages, "", tree); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		locale := normalize(strings.TrimSuffix(entry.Name(), ext))
+		if _, ok := locales[locale]; ok {
+			return nil, fmt.Errorf("%s: locale %s has several catalogs", entry.Name(), locale)
+		}
+		locales[locale] = messages
+	}
+	return New(def, locales), nil
+}
+
+// flatten adds the messages of a catalog tree to messages under prefix
+func flatten(messages map[string]Message, prefix string, node interface{}) error {
+	switch v := node.(type) {
+	case nil:
+		return nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			converted[fmt.Sprint(key)] = value
+		}
+		return flatten(messages, prefix, converted)
+	case map[string]interface{}:
+		if plural, ok := pluralForms(v); ok {
+			messages[prefix] = Message{Plural: plural}
+			return nil
+		}
+		for key, value := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if err := flatten(messages, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		return fmt.Errorf("%s: lists are not messages", prefix)
+	}
+	if prefix == "" {
+		return fmt.Errorf("catalog is not a map")
+	}
+	messages[prefix] = Message{Text: fmt.Sprint(node)}
+	return nil
+}
+
+// pluralForms returns the texts of a map of plural categories, which must have an "other" text
+func pluralForms(node map[string]interface{}) (map[string]string, bool) {
+	if _, ok := node[Other]; !ok {
+		return nil, false
+	}
+	forms := make(map[string]string, len(node))
+	for key, value := range node {
+		if !isCategory(key) {
+			return nil, false
+		}
+		text, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		forms[key] = text
+	}
+	return forms, true
+}
+
+func isCategory(key string) bool {
+	for _, category := range Categories {
+		if key == category {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize returns the lowercase form of a locale, "pt_BR" gives "pt-br"
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// chain returns the locales looked up for locale: itself, its base language and the default locale
+func (c *Catalogs) chain(locale string) []string {
+	locale = normalize(locale)
+	chain := []string{locale}
+	if base := strings.SplitN(locale, "-", 2)[0]; base != locale {
+		chain = append(chain, base)
+	}
+	if c.Default != locale {
+		chain = append(chain, c.Default)
+	}
+	return chain
+}
+
+// Locales returns the locales of the catalogs, sorted
+func (c *Catalogs) Locales() []string {
+	locales := make([]string, 0, len(c.locales))
+	for locale := range c.locales {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Has tells if there is a catalog for locale
+func (c *Catalogs) Has(locale string) bool {
+	_, ok := c.locales[normalize(locale)]
+	return ok
+}
+
+// Translate returns the text of key in locale, or in its base language and the default locale when it's missing there.
+// args are name, value pairs filling the {name} placeholders, the "count" value selects the plural form
+// with the rules of the locale the text comes from. Unknown keys give the key itself.
+func (c *Catalogs) Translate(locale, key string, args ...interface{}) string {
+	for _, l := range c.chain(locale) {
+		if message, ok := c.locales[l][key]; ok {
+			return message.format(l, args)
+		}
+	}
+	return key
+}
+
+// Messages returns all the messages of locale, the ones it misses come from its base language and the default locale
+func (c *Catalogs) Messages(locale string) map[string]Message {
+	messages := map[string]Message{}
+	chain := c.chain(locale)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for key, message := range c.locales[chain[i]] {
+			messages[key] = message
+		}
+	}
+	return messages
+}
+
+// Missing returns the keys of the default catalog missing in each other locale, sorted.
+// Locales missing no key are not listed.
+func (c *Catalogs) Missing() map[string][]string {
+	missing := map[string][]string{}
+	for locale, messages := range c.locales {
+		if locale == c.Default {
+			continue
+		}
+		for key := range c.locales[c.Default] {
+			if _, ok := messages[key]; !ok {
+				missing[locale] = append(missing[locale], key)
+			}
+		}
+		sort.Strings(missing[locale])
+	}
+	return missing
+}
+
+// format selects the plural form of the count argument and fills the placeholders
+func (m Message) format(locale string, args []interface{}) string {
+	values := map[string]string{}
+	var count interface{}
+	for i := 0; i+1 < len(args); i += 2 {
+		name := fmt.Sprint(args[i])
+		values[name] = fmt.Sprint(args[i+1])
+		if name == CountArg {
+			count = args[i+1]
+		}
+	}
+
+	text := m.Text
+	if m.Plural != nil {
+		text = m.Plural[Other]
+		if n, ok := toFloat(count); ok {
+			if zero, ok := m.Plural[Zero]; ok && n == 0 {
+				// An explicit zero text is used in every language, f.e. "No orders"
+				text = zero
+			} else if form, ok := m.Plural[PluralCategory(locale, n)]; ok {
+				text = form
+			}
+		}
+	}
+	if len(values) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(values))
+	for name, value := range values {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package i18n
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLocales = fstest.MapFS{
+	"locales/en.yml": {Data: []byte(`
+home:
+  title: Welcome
+  greeting: Hello {name}
+orders:
+  zero: No orders
+  one: "{count} order"
+  other: "{count} orders"
+only_en: English
+`)},
+	"locales/ru.yaml": {Data: []byte(`
+home:
+  title: Добро пожаловать
+orders:
+  one: "{count} заказ"
+  few: "{count} заказа"
+  many: "{count} заказов"
+  other: "{count} заказа"
+`)},
+	"locales/pt_BR.json": {Data: []byte(`{"home": {"title": "Bem-vindo"}}`)},
+	"locales/README.md":  {Data: []byte(`not a catalog`)},
+}
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		lang     string
+		n        float64
+		category string
+	}{
+		{"en", 1, One}, {"en", 0, Other}, {"en", 2, Other}, {"de", 1.5, Other},
+		{"ru", 1, One}, {"ru", 21, One}, {"ru", 11, Many}, {"ru", 3, Few}, {"ru", 13, Many}, {"ru", 24, Few}, {"ru", 5, Many}, {"ru", 1.5, Other},
+		{"uk-UA", 22, Few}, {"pl", 1, One}, {"pl", 21, Many}, {"pl", 22, Few},
+		{"cs", 3, Few}, {"cs", 5, Other}, {"fr", 0, One}, {"fr", 2, Other}, {"pt-br", 1, One},
+		{"ja", 1, Other}, {"ar", 0, Zero}, {"ar", 2, Two}, {"ar", 105, Few}, {"ar", 111, Many}, {"ar", 100, Other},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.category, PluralCategory(test.lang, test.n), "%s %v", test.lang, test.n)
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	catalogs, err := Load(testLocales, "locales", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en", "pt-br", "ru"}, catalogs.Locales())
+	assert.True(t, catalogs.Has("pt-BR"))
+	assert.False(t, catalogs.Has("de"))
+
+	assert.Equal(t, "Добро пожаловать", catalogs.Translate("ru", "home.title"))
+	assert.Equal(t, "Добро пожаловать", catalogs.Translate("ru-RU", "home.title"))
+	assert.Equal(t, "Bem-vindo", catalogs.Translate("pt-br", "home.title"))
+	assert.Equal(t, "Welcome", catalogs.Translate("de", "home.title"))
+	assert.Equal(t, "Hello Ann", catalogs.Translate("ru", "home.greeting", "name", "Ann"))
+	assert.Equal(t, "home.unknown", catalogs.Translate("en", "home.unknown"))
+
+	assert.Equal(t, "21 заказ", catalogs.Translate("ru", "orders", "count", 21))
+	assert.Equal(t, "3 заказа", catalogs.Translate("ru", "orders", "count", 3))
+	assert.Equal(t, "11 заказов", catalogs.Translate("ru", "orders", "count", "11"))
+	assert.Equal(t, "No orders", catalogs.Translate("en", "orders", "count", 0))
+	assert.Equal(t, "1 order", catalogs.Translate("pt-br", "orders", "count", 1))
+	assert.Equal(t, "{count} orders", catalogs.Translate("en", "orders"))
+
+	raw, err := json.Marshal(catalogs.Messages("ru"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"home.title": "Добро пожаловать",
+		"home.greeting": "Hello {name}",
+		"orders": {"one": "{count} заказ", "few": "{count} заказа", "many": "{count} заказов", "other": "{count} заказа"},
+		"only_en": "English"
+	}`, string(raw))
+
+	assert.Equal(t, map[string][]string{
+		"ru":    {"home.greeting", "only_en"},
+		"pt-br": {"home.greeting", "only_en", "orders"},
+	}, catalogs.Missing())
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load(fstest.MapFS{"locales/en.yml": {Data: []byte("items: [a, b]")}}, "locales", "en")
+	assert.EqualError(t, err, "en.yml: items: lists are not messages")
+	_, err = Load(fstest.MapFS{"locales/en.yml": {Data: []byte("a: b")}, "locales/EN.json": {Data: []byte("{}")}}, "locales", "en")
+	assert.EqualError(t, err, "en.yml: locale en has several catalogs")
+	_, err = Load(fstest.MapFS{}, "locales", "en")
+	assert.Error(t, err)
+}
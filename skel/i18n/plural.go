@@ -0,0 +1,126 @@
+package i18n
+
+import (
+	"math"
+	"strings"
+)
+
+// Plural categories of the CLDR, the keys of plural messages
+const (
+	Zero  = "zero"
+	One   = "one"
+	Two   = "two"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// Categories lists the plural categories
+var Categories = []string{Zero, One, Two, Few, Many, Other}
+
+// pluralRules selects the category of a count by base language, the languages missing use rule1 like English
+var pluralRules = map[string]func(n float64) string{
+	"ar": ruleArabic,
+	"be": ruleSlavic, "bs": ruleSlavic, "hr": ruleSlavic, "ru": ruleSlavic, "sr": ruleSlavic, "uk": ruleSlavic,
+	"cs": ruleCzech, "sk": ruleCzech,
+	"fr": ruleFrench, "pt": ruleFrench,
+	"pl": rulePolish,
+	"id": ruleNone, "ja": ruleNone, "ko": ruleNone, "ms": ruleNone, "th": ruleNone, "vi": ruleNone, "zh": ruleNone,
+}
+
+// PluralCategory returns the plural category of n in lang, f.e. "few" for 3 in Russian
+func PluralCategory(lang string, n float64) string {
+	base := strings.SplitN(strings.ToLower(lang), "-", 2)[0]
+	if rule, ok := pluralRules[base]; ok {
+		return rule(n)
+	}
+	return rule1(n)
+}
+
+func isInteger(n float64) bool {
+	return n == math.Trunc(n)
+}
+
+// rule1: 1 is one, the rest other, f.e. English or German
+func rule1(n float64) string {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+func ruleNone(n float64) string {
+	return Other
+}
+
+// ruleFrench: 0 and 1 are one
+func ruleFrench(n float64) string {
+	if n >= 0 && n < 2 {
+		return One
+	}
+	return Other
+}
+
+// ruleSlavic: 1, 21, 31 are one, 2-4, 22-24 few, 0, 5-20, 25-30 many, fractions other
+func ruleSlavic(n float64) string {
+	if !isInteger(n) {
+		return Other
+	}
+	i := int64(math.Abs(n))
+	switch {
+	case i%10 == 1 && i%100 != 11:
+		return One
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return Few
+	}
+	return Many
+}
+
+// rulePolish: 1 is one, 2-4, 22-24 few, the other integers many
+func rulePolish(n float64) string {
+	if !isInteger(n) {
+		return Other
+	}
+	i := int64(math.Abs(n))
+	switch {
+	case i == 1:
+		return One
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return Few
+	}
+	return Many
+}
+
+// ruleCzech: 1 is one, 2-4 few, fractions many
+func ruleCzech(n float64) string {
+	switch {
+	case !isInteger(n):
+		return Many
+	case n == 1:
+		return One
+	case n >= 2 && n <= 4:
+		return Few
+	}
+	return Other
+}
+
+// ruleArabic: 0 zero, 1 one, 2 two, 3-10 few, 11-99 many
+func ruleArabic(n float64) string {
+	if !isInteger(n) {
+		return Other
+	}
+	i := int64(math.Abs(n))
+	switch {
+	case i == 0:
+		return Zero
+	case i == 1:
+		return One
+	case i == 2:
+		return Two
+	case i%100 >= 3 && i%100 <= 10:
+		return Few
+	case i%100 >= 11:
+		return Many
+	}
+	return Other
+}
@@ -0,0 +1,159 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// MaxPixels bounds the size of the images decoded to make variants, larger images are rejected
+// before they are decoded so a small file can't take gigabytes of memory
+const MaxPixels = 50_000_000
+
+// jpegQuality is the quality of the JPEG variants
+const jpegQuality = 85
+
+// ErrImageTooLarge is returned for images of more than MaxPixels
+var ErrImageTooLarge = fmt.Errorf("image is larger than %d pixels", MaxPixels)
+
+// ErrNotImage is returned for images which can't be decoded
+var ErrNotImage = errors.New("not a decodable image")
+
+// Extensions of the accepted types, also used for the keys of the files
+var Extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// DetectType returns the MIME type of a file from its first bytes, whatever its name says
+func DetectType(head []byte) string {
+	return http.DetectContentType(head)
+}
+
+// resizable are the types decoded by the standard library, other images have no variants
+var resizable = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Variant is a resized copy of an image
+type Variant struct {
+	Width  int
+	Height int
+	Type   string
+	Data   []byte
+}
+
+// ImageSize returns the dimensions of an image, ok is false for the types without variants
+func ImageSize(contentType string, data []byte) (width, height int, ok bool, err error) {
+	if !resizable[contentType] {
+		return 0, 0, false, nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("%w: %s", ErrNotImage, err)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return 0, 0, false, ErrImageTooLarge
+	}
+	return cfg.Width, cfg.Height, true, nil
+}
+
+// Variants returns a copy of the image for each of widths smaller than it, widest first.
+// GIFs lose their animation and become PNG, the other images keep their type.
+func Variants(contentType string, data []byte, widths []int) ([]Variant, error) {
+	width, _, ok, err := ImageSize(contentType, data)
+	if err != nil || !ok {
+		return nil, err
+	}
+	smaller := []int{}
+	for _, w := range widths {
+		if w > 0 && w < width {
+			smaller = append(smaller, w)
+		}
+	}
+	if len(smaller) == 0 {
+		return nil, nil
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(smaller)))
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotImage, err)
+	}
+	variants := []Variant{}
+	for _, w := range smaller {
+		resized := Resize(src, w)
+		variant := Variant{Width: resized.Bounds().Dx(), Height: resized.Bounds().Dy(), Type: contentType}
+		var buf bytes.Buffer
+		if err := encode(&buf, resized, &variant); err != nil {
+			return nil, err
+		}
+		variant.Data = buf.Bytes()
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+func encode(w io.Writer, img image.Image, variant *Variant) error {
+	switch variant.Type {
+	case "image/jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case "image/gif":
+		variant.Type = "image/png"
+	}
+	return png.Encode(w, img)
+}
+
+// Resize scales an image down to width keeping its ratio, each pixel is the average of the pixels it covers
+func Resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	height := (sh*width + sw/2) / sw
+	if height < 1 {
+		height = 1
+	}
+
+	// Averages are computed on premultiplied colors so transparent pixels don't darken the edges
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride+x0*4 : sy*rgba.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					b += uint64(row[i+2])
+					a += uint64(row[i+3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
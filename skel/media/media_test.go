@@ -0,0 +1,77 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x % 2 * 255), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestLocalStorage(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "/media/")
+	require.NoError(t, s.Put("2021/06/a.txt", bytes.NewReader([]byte("hello"))))
+	r, err := s.Open("2021/06/a.txt")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, "/media/2021/06/a.txt", s.URL("2021/06/a.txt"))
+
+	require.NoError(t, s.Delete("2021/06/a.txt"))
+	require.NoError(t, s.Delete("2021/06/a.txt"))
+	_, err = s.Open("2021/06/a.txt")
+	assert.Error(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../a.txt", "a/../../b", "a//b"} {
+		assert.Equal(t, ErrInvalidKey, s.Put(key, bytes.NewReader(nil)), key)
+	}
+}
+
+func TestVariants(t *testing.T) {
+	data := testPNG(t, 100, 50)
+	assert.Equal(t, "image/png", DetectType(data))
+	assert.Equal(t, "application/pdf", DetectType([]byte("%PDF-1.4\n")))
+
+	width, height, ok, err := ImageSize("image/png", data)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{100, 50}, []int{width, height})
+	_, _, ok, err = ImageSize("application/pdf", []byte("%PDF-1.4\n"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, _, _, err = ImageSize("image/png", []byte("\x89PNG\r\n\x1a\nbroken"))
+	assert.ErrorIs(t, err, ErrNotImage)
+
+	variants, err := Variants("image/png", data, []int{10, 200, 40, 100})
+	require.NoError(t, err)
+	require.Len(t, variants, 2)
+	assert.Equal(t, 40, variants[0].Width)
+	assert.Equal(t, 20, variants[0].Height)
+	assert.Equal(t, 10, variants[1].Width)
+	assert.Equal(t, 5, variants[1].Height)
+	img, err := png.Decode(bytes.NewReader(variants[1].Data))
+	require.NoError(t, err)
+	// Alternating red and black columns average to dark red
+	r, g, _, a := img.At(3, 2).RGBA()
+	assert.InDelta(t, 0x7f7f, r, 0x200)
+	assert.Equal(t, uint32(0), g)
+	assert.Equal(t, uint32(0xffff), a)
+}
@@ -0,0 +1,97 @@
+// Package media stores the files of the media library and resizes images
+package media
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage keeps the uploaded files, keys are slash separated paths like 2021/06/1a2b.png
+type Storage interface {
+	// Put writes the content of r at key, replacing the file at key if there is one
+	Put(key string, r io.Reader) error
+	// Open returns the content of key
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes key, missing files are ignored
+	Delete(key string) error
+	// URL returns the address the file at key is served at
+	URL(key string) string
+}
+
+// ErrInvalidKey is returned for keys which are not clean relative paths
+var ErrInvalidKey = errors.New("invalid media key")
+
+// LocalStorage keeps the files in a directory, served at the prefix of their URL
+type LocalStorage struct {
+	Dir    string
+	Prefix string
+}
+
+// NewLocalStorage returns a storage writing in dir, its files are served at prefix
+func NewLocalStorage(dir, prefix string) *LocalStorage {
+	return &LocalStorage{Dir: dir, Prefix: strings.TrimSuffix(prefix, "/")}
+}
+
+// Put writes the file in a temporary file renamed at the end, readers never see a partial file
+func (s *LocalStorage) Put(key string, r io.Reader) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.Prefix + "/" + key
+}
+
+// path returns the file of key, keys can't leave the directory
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || path.IsAbs(key) || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
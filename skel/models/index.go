@@ -1,11 +1,15 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"github.com/openware/pkg/sonic/config"
-	"io/ioutil"
+	"io/fs"
 	"log"
+	"os"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
 	"gorm.io/gorm"
 )
 
@@ -23,6 +27,9 @@ type MetaModel struct {
 var db *gorm.DB
 var app *config.Runtime
 
+// files holds the seeds, embedded in the binary or read from the working directory
+var files fs.FS = os.DirFS(".")
+
 // Models contains the list of registered models of the application
 var registry = []MetaModel{}
 
@@ -33,25 +40,55 @@ func Setup(apr *config.Runtime) {
 	db = apr.DB
 }
 
+// SetFiles sets the file system containing config/seeds
+func SetFiles(fsys fs.FS) {
+	files = fsys
+}
+
 // Register a model to the framework
 func Register(name string, model interface{}, ptr LoaderFunc) {
 	registry = append(registry, MetaModel{name, model, ptr})
 }
 
+// BeforeMigrator is implemented by the models changing their table before it's migrated,
+// f.e. to drop an index AutoMigrate would keep
+type BeforeMigrator interface {
+	BeforeMigrate(tx *gorm.DB) error
+}
+
+// AfterMigrator is implemented by the models setting up more than their table once it's migrated,
+// f.e. a search index
+type AfterMigrator interface {
+	AfterMigrate(tx *gorm.DB) error
+}
+
 // Migrate create and modify database tables according to the models
 func Migrate() error {
 	for _, meta := range registry {
 		log.Printf("Migrating %s\n", meta.Name)
+		if m, ok := meta.Model.(BeforeMigrator); ok {
+			if err := m.BeforeMigrate(db); err != nil {
+				return err
+			}
+		}
 		if err := db.AutoMigrate(meta.Model); err != nil {
 			return err
 		}
+		if m, ok := meta.Model.(AfterMigrator); ok {
+			if err := m.AfterMigrate(db); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
-// Seed execute all table seeding from yaml
+// Seed execute all table seeding from yaml, models registered without loader have no seed
 func Seed() error {
 	for _, meta := range registry {
+		if meta.Loader == nil {
+			continue
+		}
 		if err := readYamlSeed(meta); err != nil {
 			return err
 		}
@@ -62,7 +99,7 @@ func Seed() error {
 // TODO: replace Loader function by reading from a map
 func readYamlSeed(meta MetaModel) error {
 	filename := fmt.Sprintf("config/seeds/%s.yml", meta.Name)
-	raw, err := ioutil.ReadFile(filename)
+	raw, err := fs.ReadFile(files, filename)
 	if err != nil {
 		return err
 	}
@@ -73,3 +110,13 @@ func readYamlSeed(meta MetaModel) error {
 	tx := db.CreateInBatches(list, 1000)
 	return tx.Error
 }
+
+// duplicateKey tells if err is the violation of a unique index by mysql or sqlite
+func duplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
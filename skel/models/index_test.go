@@ -12,6 +12,12 @@ func InitTestDB() {
 	if err != nil {
 		panic(err)
 	}
+	// Every connection to :memory: has its own database
+	sql, err := db.DB()
+	if err != nil {
+		panic(err)
+	}
+	sql.SetMaxOpenConns(1)
 
 	Setup(&sonic.Runtime{
 		DB: db,
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/openware/pkg/database"
+	"gorm.io/gorm"
+)
+
+func init() {
+	Register("media", &Media{}, nil)
+	Register("media_variants", &MediaVariant{}, nil)
+}
+
+// Media is a file of the media library, Key is its path in the storage and Name the name it was uploaded with.
+// Images have their dimensions and resized variants.
+type Media struct {
+	ID       uint           `gorm:"primarykey"`
+	Key      string         `gorm:"size:255;not null;uniqueIndex"`
+	Name     string         `gorm:"size:255;not null"`
+	Type     string         `gorm:"size:64;not null"`
+	Size     int64          `gorm:"not null"`
+	Width    int            `gorm:"not null;default:0"`
+	Height   int            `gorm:"not null;default:0"`
+	Alt      string         `gorm:"size:255"`
+	Author   string         `gorm:"size:255"`
+	Variants []MediaVariant `gorm:"constraint:OnDelete:CASCADE"`
+	database.Timestamps
+}
+
+// TableName keeps media uncountable
+func (Media) TableName() string {
+	return "media"
+}
+
+// MediaVariant is a resized copy of an image of the media library
+type MediaVariant struct {
+	ID      uint   `gorm:"primarykey"`
+	MediaID uint   `gorm:"not null;index"`
+	Key     string `gorm:"size:255;not null;uniqueIndex"`
+	Type    string `gorm:"size:64;not null"`
+	Size    int64  `gorm:"not null"`
+	Width   int    `gorm:"not null"`
+	Height  int    `gorm:"not null"`
+}
+
+// Keys returns the storage keys of the file and of its variants
+func (m *Media) Keys() []string {
+	keys := []string{m.Key}
+	for _, variant := range m.Variants {
+		keys = append(keys, variant.Key)
+	}
+	return keys
+}
+
+// ListMedia returns the media of a page of the library, latest first, and the number of media
+func ListMedia(offset, limit int) ([]Media, int64, error) {
+	var total int64
+	if err := db.Model(&Media{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := []Media{}
+	err := db.Preload("Variants", orderByWidth).Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error
+	return list, total, err
+}
+
+// FindMedia returns the media with this id and its variants, nil if there is none
+func FindMedia(id uint) (*Media, error) {
+	m := Media{}
+	err := db.Preload("Variants", orderByWidth).Take(&m, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateMedia saves a media with its variants
+func CreateMedia(m *Media) error {
+	return db.Create(m).Error
+}
+
+// DeleteMedia removes the media with this id and its variants, and returns it to remove its files, nil if there was none
+func DeleteMedia(id uint) (*Media, error) {
+	m, err := FindMedia(id)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("media_id = ?", id).Delete(&MediaVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Media{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// orderByWidth lists the variants widest first
+func orderByWidth(tx *gorm.DB) *gorm.DB {
+	return tx.Order("width DESC")
+}
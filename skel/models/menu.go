@@ -0,0 +1,252 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/openware/pkg/database"
+	"gopkg.in/yaml.v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	Register("menus", &Menu{}, func(rawYaml []byte) (interface{}, error) {
+		list := []Menu{}
+		err := yaml.Unmarshal(rawYaml, &list)
+		return list, err
+	})
+	Register("menu_items", &MenuItem{}, nil)
+}
+
+// MaxMenuDepth is the number of levels of a menu, items of the last level have no children
+const MaxMenuDepth = 3
+
+// menuNameFormat accepts the names used by the views, f.e. header or footer-legal
+var menuNameFormat = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// ErrMenuNameTaken is returned when saving a menu with the name of another one
+var ErrMenuNameTaken = errors.New("name is already used by another menu")
+
+// Menu is a named tree of links shown by the views and the frontend, f.e. header or footer.
+// Items are saved with their menu and replace the previous ones.
+type Menu struct {
+	ID    uint       `gorm:"primarykey" yaml:"-"`
+	Name  string     `gorm:"size:64;not null;uniqueIndex" yaml:"name"`
+	Items []MenuItem `gorm:"-" yaml:"items"`
+	database.Timestamps
+}
+
+// MenuItem links to the page or client route at Path, or to URL.
+// Label is its text, Labels overrides it by language.
+type MenuItem struct {
+	ID       uint       `gorm:"primarykey" yaml:"-"`
+	MenuID   uint       `gorm:"not null;index" yaml:"-"`
+	ParentID *uint      `gorm:"index" yaml:"-"`
+	Position int        `gorm:"not null;default:0" yaml:"-"`
+	Label    string     `gorm:"size:255;not null" yaml:"label"`
+	Labels   Labels     `gorm:"type:text" yaml:"labels"`
+	Path     string     `gorm:"size:255" yaml:"path"`
+	URL      string     `gorm:"size:255" yaml:"url"`
+	Children []MenuItem `gorm:"-" yaml:"children"`
+}
+
+// Labels are texts by language, stored as a JSON object
+type Labels map[string]string
+
+// Value implements driver.Valuer
+func (l Labels) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(l)
+	return string(raw), err
+}
+
+// Scan implements sql.Scanner
+func (l *Labels) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*l = Labels{}
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("can't scan labels from %T", value)
+	}
+	labels := Labels{}
+	if err := json.Unmarshal(raw, &labels); err != nil {
+		return err
+	}
+	*l = labels
+	return nil
+}
+
+// Text returns the label of the item in the first of langs it has one, Label otherwise
+func (i *MenuItem) Text(langs ...string) string {
+	for _, lang := range langs {
+		if label, ok := i.Labels[NormalizeLang(lang)]; ok && label != "" {
+			return label
+		}
+	}
+	return i.Label
+}
+
+// Validate checks the name of the menu and its items
+func (m *Menu) Validate() error {
+	if !menuNameFormat.MatchString(m.Name) {
+		return fmt.Errorf("invalid name %q, expected lowercase letters, digits, - and _", m.Name)
+	}
+	return validateMenuItems(m.Items, 1)
+}
+
+func validateMenuItems(items []MenuItem, depth int) error {
+	for i := range items {
+		item := &items[i]
+		if depth > MaxMenuDepth {
+			return fmt.Errorf("menus have at most %d levels", MaxMenuDepth)
+		}
+		if strings.TrimSpace(item.Label) == "" {
+			return errors.New("label of menu item is required")
+		}
+		for lang := range item.Labels {
+			if !langFormat.MatchString(lang) {
+				return fmt.Errorf("invalid language %q, expected a lowercase tag like en or pt-br", lang)
+			}
+		}
+		switch {
+		case (item.Path == "") == (item.URL == ""):
+			return fmt.Errorf("menu item %q needs either a path or a url", item.Label)
+		case item.Path != "" && !strings.HasPrefix(item.Path, "/"):
+			return fmt.Errorf("invalid path %q, expected an absolute path", item.Path)
+		case item.URL != "" && !strings.HasPrefix(item.URL, "https://") && !strings.HasPrefix(item.URL, "http://") && !strings.HasPrefix(item.URL, "mailto:"):
+			return fmt.Errorf("invalid url %q, expected an http(s) or mailto URL", item.URL)
+		}
+		if err := validateMenuItems(item.Children, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeforeSave normalizes the languages of the labels and validates the menu
+func (m *Menu) BeforeSave(tx *gorm.DB) error {
+	normalizeLabels(m.Items)
+	return m.Validate()
+}
+
+func normalizeLabels(items []MenuItem) {
+	for i := range items {
+		labels := Labels{}
+		for lang, label := range items[i].Labels {
+			labels[NormalizeLang(lang)] = label
+		}
+		items[i].Labels = labels
+		normalizeLabels(items[i].Children)
+	}
+}
+
+// AfterSave replaces the items of the menu by its tree, seeds and the admin API save menus as a whole
+func (m *Menu) AfterSave(tx *gorm.DB) error {
+	if err := tx.Where("menu_id = ?", m.ID).Delete(&MenuItem{}).Error; err != nil {
+		return err
+	}
+	return createMenuItems(tx, m.ID, nil, m.Items)
+}
+
+func createMenuItems(tx *gorm.DB, menuID uint, parentID *uint, items []MenuItem) error {
+	for i := range items {
+		item := &items[i]
+		item.ID, item.MenuID, item.ParentID, item.Position = 0, menuID, parentID, i
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		if err := createMenuItems(tx, menuID, &item.ID, item.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListMenus returns the menus by name, without their items
+func ListMenus() ([]Menu, error) {
+	menus := []Menu{}
+	err := db.Order("name").Find(&menus).Error
+	return menus, err
+}
+
+// FindMenu returns the menu with this id and its items, nil if there is none
+func FindMenu(id uint) (*Menu, error) {
+	return findMenu("id = ?", id)
+}
+
+// FindMenuByName returns the menu with this name and its items, nil if there is none
+func FindMenuByName(name string) (*Menu, error) {
+	return findMenu("name = ?", name)
+}
+
+func findMenu(query string, args ...interface{}) (*Menu, error) {
+	menu := Menu{}
+	if err := db.Where(query, args...).First(&menu).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	items := []MenuItem{}
+	if err := db.Where("menu_id = ?", menu.ID).Order("position, id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	menu.Items = menuTree(items, nil)
+	return &menu, nil
+}
+
+// menuTree returns the items under parent, in order, with their children
+func menuTree(items []MenuItem, parent *uint) []MenuItem {
+	tree := []MenuItem{}
+	for _, item := range items {
+		if (parent == nil) != (item.ParentID == nil) || (parent != nil && *parent != *item.ParentID) {
+			continue
+		}
+		item.Children = menuTree(items, &item.ID)
+		tree = append(tree, item)
+	}
+	return tree
+}
+
+// SaveMenu creates or updates a menu with its items, ErrMenuNameTaken is returned if another menu has its name
+func SaveMenu(menu *Menu) error {
+	var count int64
+	if err := db.Model(&Menu{}).Where("name = ? AND id <> ?", menu.Name, menu.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrMenuNameTaken
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if menu.ID == 0 {
+			return tx.Create(menu).Error
+		}
+		return tx.Save(menu).Error
+	})
+}
+
+// DeleteMenu removes the menu with this id and its items, and tells if it existed
+func DeleteMenu(id uint) (bool, error) {
+	found := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Delete(&Menu{}, id)
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		found = deleted.RowsAffected > 0
+		return tx.Where("menu_id = ?", id).Delete(&MenuItem{}).Error
+	})
+	return found, err
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMenuModel(t *testing.T) {
+	InitTestDB()
+	menu := Menu{Name: "header", Items: []MenuItem{
+		{Label: "Trading", Path: "/trading", Children: []MenuItem{
+			{Label: "Spot", Labels: Labels{"RU": "Спот"}, Path: "/trading/spot"},
+			{Label: "API", URL: "https://docs.openware.com"},
+		}},
+		{Label: "Terms", Path: "/terms"},
+	}}
+	require.NoError(t, SaveMenu(&menu))
+	assert.Equal(t, ErrMenuNameTaken, SaveMenu(&Menu{Name: "header"}))
+
+	saved, err := FindMenuByName("header")
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	require.Len(t, saved.Items, 2)
+	assert.Equal(t, "Trading", saved.Items[0].Label)
+	assert.Equal(t, "Terms", saved.Items[1].Label)
+	require.Len(t, saved.Items[0].Children, 2)
+	spot := saved.Items[0].Children[0]
+	assert.Equal(t, "Спот", spot.Text("ru-ru", "ru"))
+	assert.Equal(t, "Spot", spot.Text("en"))
+	assert.Equal(t, "https://docs.openware.com", saved.Items[0].Children[1].URL)
+
+	// Items are replaced on update
+	saved.Items = saved.Items[1:]
+	require.NoError(t, SaveMenu(saved))
+	saved, err = FindMenu(saved.ID)
+	require.NoError(t, err)
+	require.Len(t, saved.Items, 1)
+	var count int64
+	require.NoError(t, db.Model(&MenuItem{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	found, err := DeleteMenu(saved.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	saved, err = FindMenuByName("header")
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+}
+
+func TestMenuValidation(t *testing.T) {
+	deep := []MenuItem{{Label: "4", Path: "/4"}}
+	for i := 3; i > 0; i-- {
+		deep = []MenuItem{{Label: "level", Path: "/level", Children: deep}}
+	}
+	tests := []struct {
+		menu Menu
+		err  string
+	}{
+		{Menu{Name: "Header"}, `invalid name "Header", expected lowercase letters, digits, - and _`},
+		{Menu{Name: "footer", Items: []MenuItem{{Label: " ", Path: "/"}}}, "label of menu item is required"},
+		{Menu{Name: "footer", Items: []MenuItem{{Label: "Both", Path: "/terms", URL: "https://example.com"}}}, `menu item "Both" needs either a path or a url`},
+		{Menu{Name: "footer", Items: []MenuItem{{Label: "None"}}}, `menu item "None" needs either a path or a url`},
+		{Menu{Name: "footer", Items: []MenuItem{{Label: "Terms", Path: "terms"}}}, `invalid path "terms", expected an absolute path`},
+		{Menu{Name: "footer", Items: []MenuItem{{Label: "XSS", URL: "javascript:alert(1)"}}}, `invalid url "javascript:alert(1)", expected an http(s) or mailto URL`},
+		{Menu{Name: "footer", Items: []MenuItem{{Label: "Terms", Labels: Labels{"Russian": "Условия"}, Path: "/terms"}}}, `invalid language "Russian", expected a lowercase tag like en or pt-br`},
+		{Menu{Name: "footer", Items: deep}, "menus have at most 3 levels"},
+		{Menu{Name: "footer", Items: deep[0].Children}, ""},
+	}
+	for _, test := range tests {
+		err := test.menu.Validate()
+		if test.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
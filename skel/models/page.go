@@ -2,10 +2,15 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"github.com/openware/pkg/sonic/models"
 	"log"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/openware/pkg/database"
+	"github.com/openware/sonic/skel/content"
 	"gopkg.in/yaml.v2"
 	"gorm.io/gorm"
 )
@@ -19,16 +24,131 @@ func init() {
 }
 
 // Page : Table name is `Pages`
+// Pages are identified by path and language, a lowercase tag like en or pt-br.
+// Pages without language are served in every language.
 type Page struct {
 	ID          uint   `gorm:"primarykey"`
-	Path        string `gorm:"uniqueIndex;size:64;not null" yaml:"path"`
-	Lang        string `yaml:"lang"`
+	Path        string `gorm:"uniqueIndex:idx_pages_path_lang;size:64;not null" yaml:"path"`
+	Lang        string `gorm:"uniqueIndex:idx_pages_path_lang;size:16;not null;default:''" yaml:"lang"`
 	Title       string `yaml:"title"`
 	Description string `yaml:"description"`
 	Body        string `yaml:"body"`
+	// SEO metadata, Open Graph and Twitter fields default to the title and description
+	Keywords      string `yaml:"keywords"`
+	CanonicalURL  string `gorm:"size:255" yaml:"canonical_url"`
+	OGTitle       string `gorm:"size:255" yaml:"og_title"`
+	OGDescription string `yaml:"og_description"`
+	OGImage       string `gorm:"size:255" yaml:"og_image"`
+	TwitterCard   string `gorm:"size:32" yaml:"twitter_card"`
+	NoIndex       bool   `gorm:"not null;default:false" yaml:"noindex"`
+	// Format of the body, markdown, html or plain
+	Format string `gorm:"size:16;not null;default:markdown" yaml:"format"`
+	// Version is incremented on every update, rendered bodies are cached by version
+	Version uint `gorm:"not null;default:1" yaml:"-"`
+	// Status is draft, scheduled, published or archived, only live pages are served
+	Status      string     `gorm:"size:16;not null;default:published;index" yaml:"status"`
+	PublishAt   *time.Time `yaml:"publish_at"`
+	UnpublishAt *time.Time `yaml:"unpublish_at"`
 	database.Timestamps
 }
 
+// MaxPathLength is the size of the path column
+const MaxPathLength = 64
+
+// pathFormat accepts absolute paths of unreserved URL characters, without empty segments or trailing slash
+var pathFormat = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// langFormat accepts lowercase language tags, f.e. en, pt-br or zh-hant
+var langFormat = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// TwitterCards lists the Twitter card types, empty is summary or summary_large_image with an image
+var TwitterCards = []string{"summary", "summary_large_image"}
+
+// ErrPathTaken is returned when saving a page at the path and language of another one
+var ErrPathTaken = errors.New("path is already used by another page in this language")
+
+// NormalizeLang returns the form of a language tag stored in pages, "pt_BR" gives "pt-br"
+func NormalizeLang(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+// Validate checks the path, the title and the content format
+func (p *Page) Validate() error {
+	if len(p.Path) > MaxPathLength {
+		return fmt.Errorf("path is longer than %d characters", MaxPathLength)
+	}
+	if !pathFormat.MatchString(p.Path) {
+		return fmt.Errorf("invalid path %q, expected segments of letters, digits, '.', '_', '~' or '-' like /legal/terms", p.Path)
+	}
+	if p.Lang != "" && !langFormat.MatchString(p.Lang) {
+		return fmt.Errorf("invalid language %q, expected a lowercase tag like en or pt-br", p.Lang)
+	}
+	if strings.TrimSpace(p.Title) == "" {
+		return errors.New("title is required")
+	}
+	if err := p.validateSEO(); err != nil {
+		return err
+	}
+	if !contains(content.Formats, p.Format) {
+		return fmt.Errorf("invalid page format %q, expected one of %s", p.Format, strings.Join(content.Formats, ", "))
+	}
+	return p.validateSchedule()
+}
+
+func (p *Page) validateSEO() error {
+	for name, value := range map[string]string{"canonical_url": p.CanonicalURL, "og_image": p.OGImage} {
+		if value != "" && !strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+			return fmt.Errorf("invalid %s %q, expected an absolute path or an http(s) URL", name, value)
+		}
+	}
+	if p.TwitterCard != "" && !contains(TwitterCards, p.TwitterCard) {
+		return fmt.Errorf("invalid twitter_card %q, expected one of %s", p.TwitterCard, strings.Join(TwitterCards, ", "))
+	}
+	return nil
+}
+
+// BeforeSave normalizes the language, sets the default format and status, then validates the page.
+// Pages without status are published, like the ones created before statuses existed.
+func (p *Page) BeforeSave(tx *gorm.DB) error {
+	p.Lang = NormalizeLang(p.Lang)
+	if p.Format == "" {
+		p.Format = content.FormatMarkdown
+	}
+	if p.Status == "" {
+		p.Status = PagePublished
+	}
+	return p.Validate()
+}
+
+// BeforeMigrate replaces the unique index of the path by the one of the path and language,
+// and normalizes the languages of the pages created before
+func (p *Page) BeforeMigrate(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if !migrator.HasTable(p) {
+		return nil
+	}
+	if migrator.HasIndex(p, "idx_pages_path") {
+		if err := migrator.DropIndex(p, "idx_pages_path"); err != nil {
+			return err
+		}
+	}
+	return tx.Model(p).
+		Where("lang IS NULL OR lang <> LOWER(lang)").
+		UpdateColumn("lang", gorm.Expr("LOWER(COALESCE(lang, ''))")).Error
+}
+
+// BeforeUpdate increments the version of the page
+func (p *Page) BeforeUpdate(tx *gorm.DB) error {
+	p.Version++
+	tx.Statement.SetColumn("version", p.Version)
+	return nil
+}
+
+// Render converts the body to HTML according to its format
+func (p *Page) Render() (content.Rendered, error) {
+	return content.Render(p.Format, p.Body)
+}
+
 func (p *Page) GetPath() string {
 	return p.Path
 }
@@ -51,7 +171,7 @@ func (p *Page) GetDescription() string {
 // FindByPath find and return a page by path
 func (p *Page) FindByPath(path string) *Page {
 	page := Page{}
-	tx := db.Where("path = ?", path).First(&page)
+	tx := live(db, time.Now()).Where("path = ?", path).First(&page)
 
 	if tx.Error != nil {
 		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
@@ -66,17 +186,150 @@ func (p *Page) Transform() models.IPage {
 	return p
 }
 
-// List returns all pages
+// List returns all live pages
 func (p *Page) List() []models.IPage {
 	var pages []Page
-	tx := db.Find(&pages)
+	tx := live(db, time.Now()).Find(&pages)
 
 	if tx.Error != nil {
 		log.Fatalf("FindPageByPath failed: %s", tx.Error.Error())
 	}
 	allPages := make([]models.IPage, len(pages))
-	for idx, ent := range pages {
-		allPages[idx] = ent.Transform()
+	for idx := range pages {
+		allPages[idx] = pages[idx].Transform()
 	}
 	return allPages
 }
+
+// ListPages returns limit pages ordered by path from offset, with the total number of pages.
+// Pages are filtered by language unless lang is empty.
+func ListPages(lang string, offset, limit int) ([]Page, int64, error) {
+	query := db.Model(&Page{})
+	if lang != "" {
+		query = query.Where("lang = ?", NormalizeLang(lang))
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	pages := []Page{}
+	err := query.Order("path").Offset(offset).Limit(limit).Find(&pages).Error
+	return pages, total, err
+}
+
+// FindPage returns the page with this id, or nil if there is none
+func FindPage(id uint) (*Page, error) {
+	return findPage(db, "id = ?", id)
+}
+
+// ListLivePages returns the live pages, ordered by path and language
+func ListLivePages() ([]Page, error) {
+	pages := []Page{}
+	err := live(db, time.Now()).Order("path, lang").Find(&pages).Error
+	return pages, err
+}
+
+// FindPageByPath returns the live page at path in the first of langs it's written in, nil if there is none,
+// and the languages of all the live pages at path
+func FindPageByPath(path string, langs []string) (*Page, []string, error) {
+	pages := []Page{}
+	if err := live(db, time.Now()).Where("path = ?", path).Order("lang").Find(&pages).Error; err != nil {
+		return nil, nil, err
+	}
+	available := make([]string, len(pages))
+	for i := range pages {
+		available[i] = pages[i].Lang
+	}
+	for _, lang := range langs {
+		for i := range pages {
+			if pages[i].Lang == lang {
+				return &pages[i], available, nil
+			}
+		}
+	}
+	return nil, available, nil
+}
+
+func findPage(tx *gorm.DB, query string, args ...interface{}) (*Page, error) {
+	page := Page{}
+	if err := tx.Where(query, args...).First(&page).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &page, nil
+}
+
+// SavePage creates or updates a page and stores its content as a new revision by author,
+// ErrPathTaken is returned if another page has its path. Moved pages leave a redirect at their old path.
+func SavePage(page *Page, author string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Page{}).Where("path = ? AND lang = ? AND id <> ?", page.Path, NormalizeLang(page.Lang), page.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrPathTaken
+		}
+		var err error
+		if page.ID == 0 {
+			err = pathTaken(tx.Create(page).Error)
+		} else {
+			err = updatePage(tx, page)
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Create(newPageRevision(page, author)).Error
+	})
+}
+
+// updatePage saves a page, the old path of a page which was not a draft is redirected to the new one.
+// Redirects don't have a language, so the old path is not redirected while a page in another language is there.
+func updatePage(tx *gorm.DB, page *Page) error {
+	old := Page{}
+	if err := tx.Select("path", "status").Take(&old, page.ID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err := pathTaken(tx.Save(page).Error); err != nil {
+		return err
+	}
+	if old.Path == "" || old.Path == page.Path || old.Status == PageDraft {
+		return nil
+	}
+	var remaining int64
+	if err := tx.Model(&Page{}).Where("path = ?", old.Path).Count(&remaining).Error; err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return nil
+	}
+	return redirectMovedPage(tx, old.Path, page.Path)
+}
+
+// pathTaken returns ErrPathTaken for the violation of the unique index of the path and language,
+// a concurrent save can pass the check of SavePage and is rejected by the index then
+func pathTaken(err error) error {
+	if duplicateKey(err) {
+		return ErrPathTaken
+	}
+	return err
+}
+
+// DeletePage removes the page with this id and its revisions, and tells if it existed
+func DeletePage(id uint) (bool, error) {
+	found := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Delete(&Page{}, id)
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		found = deleted.RowsAffected > 0
+		if err := search.remove(tx, id); err != nil {
+			return err
+		}
+		return tx.Where("page_id = ?", id).Delete(&PageRevision{}).Error
+	})
+	return found, err
+}
@@ -53,6 +53,117 @@ func TestPageModel(t *testing.T) {
 	assertPagesEqual(t, &h, hi)
 	assertPagesEqual(t, &c, ci)
 
-	assertPagesSliceEqual(t, pages, p.List())
+	list := []Page{}
+	for _, page := range p.List() {
+		list = append(list, *page.(*Page))
+	}
+	assertPagesSliceEqual(t, pages, list)
 	assert.Nil(t, p.FindByPath("/terms"))
 }
+
+func TestPageVersion(t *testing.T) {
+	InitTestDB()
+	page := Page{Path: "/faq", Title: "FAQ", Body: "# Questions"}
+	require.NoError(t, db.Create(&page).Error)
+	assert.Equal(t, uint(1), page.Version)
+	assert.Equal(t, "markdown", page.Format)
+
+	page.Body = "# Answers"
+	require.NoError(t, db.Save(&page).Error)
+	require.NoError(t, db.Model(&page).Updates(map[string]interface{}{"title": "Help"}).Error)
+	saved := page.FindByPath("/faq")
+	require.NotNil(t, saved)
+	assert.Equal(t, uint(3), saved.Version)
+	assert.Equal(t, "Help", saved.Title)
+
+	page.Format = "rst"
+	assert.EqualError(t, db.Save(&page).Error, `invalid page format "rst", expected one of markdown, html, plain`)
+
+	rendered, err := saved.Render()
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered.HTML), `<h1 id="answers">Answers`)
+}
+
+func TestPageLanguages(t *testing.T) {
+	InitTestDB()
+	for _, page := range []Page{
+		{Path: "/terms", Lang: "EN", Title: "Terms"},
+		{Path: "/terms", Lang: "ru", Title: "Условия"},
+		{Path: "/terms", Lang: "de", Title: "AGB", Status: PageDraft},
+		{Path: "/faq", Title: "FAQ"},
+	} {
+		require.NoError(t, SavePage(&page, ""))
+	}
+	assert.Equal(t, ErrPathTaken, SavePage(&Page{Path: "/terms", Lang: "ru", Title: "Copy"}, ""))
+	assert.Equal(t, ErrPathTaken, pathTaken(db.Create(&Page{Path: "/terms", Lang: "ru", Title: "Copy"}).Error))
+
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			errs <- SavePage(&Page{Path: "/fees", Lang: "en", Title: "Fees"}, "")
+		}()
+	}
+	taken := 0
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			assert.Equal(t, ErrPathTaken, err)
+			taken++
+		}
+	}
+	assert.Equal(t, cap(errs)-1, taken)
+
+	page, langs, err := FindPageByPath("/terms", []string{"de", "ru", "en", ""})
+	require.NoError(t, err)
+	require.NotNil(t, page)
+	assert.Equal(t, "Условия", page.Title)
+	assert.Equal(t, []string{"en", "ru"}, langs)
+
+	page, _, err = FindPageByPath("/terms", []string{"fr", ""})
+	require.NoError(t, err)
+	assert.Nil(t, page)
+	page, langs, err = FindPageByPath("/faq", []string{"fr", ""})
+	require.NoError(t, err)
+	require.NotNil(t, page)
+	assert.Equal(t, []string{""}, langs)
+
+	assert.EqualError(t, (&Page{Path: "/terms", Lang: "English", Title: "Terms", Format: "markdown", Status: PagePublished}).Validate(),
+		`invalid language "English", expected a lowercase tag like en or pt-br`)
+}
+
+func TestPageMigration(t *testing.T) {
+	InitTestDB()
+	// Table of the pages before languages, with a unique path and uppercase languages
+	require.NoError(t, db.Migrator().DropTable(&Page{}))
+	require.NoError(t, db.Exec("CREATE TABLE pages (id integer PRIMARY KEY, path varchar(64) NOT NULL, lang text, title text, description text, body text, created_at datetime, updated_at datetime)").Error)
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_pages_path ON pages(path)").Error)
+	require.NoError(t, db.Exec("INSERT INTO pages (path, lang, title) VALUES ('/terms', 'EN', 'Terms'), ('/faq', NULL, 'FAQ')").Error)
+
+	require.NoError(t, Migrate())
+	assert.False(t, db.Migrator().HasIndex(&Page{}, "idx_pages_path"))
+	require.NoError(t, SavePage(&Page{Path: "/terms", Lang: "ru", Title: "Условия"}, ""))
+	page, langs, err := FindPageByPath("/terms", []string{"en"})
+	require.NoError(t, err)
+	require.NotNil(t, page)
+	assert.Equal(t, []string{"en", "ru"}, langs)
+	page, _, err = FindPageByPath("/faq", []string{""})
+	require.NoError(t, err)
+	assert.NotNil(t, page)
+}
+
+func TestPageSEO(t *testing.T) {
+	InitTestDB()
+	page := Page{Path: "/fees", Title: "Fees", OGImage: "/public/fees.png", CanonicalURL: "https://example.com/fees", TwitterCard: "summary", NoIndex: true}
+	require.NoError(t, SavePage(&page, ""))
+	require.NoError(t, SavePage(&Page{Path: "/draft", Title: "Draft", Status: PageDraft}, ""))
+
+	pages, err := ListLivePages()
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	assert.True(t, pages[0].NoIndex)
+	assert.Equal(t, "/public/fees.png", pages[0].OGImage)
+
+	page.CanonicalURL = "example.com/fees"
+	assert.EqualError(t, page.Validate(), `invalid canonical_url "example.com/fees", expected an absolute path or an http(s) URL`)
+	page.CanonicalURL, page.TwitterCard = "", "player"
+	assert.EqualError(t, page.Validate(), `invalid twitter_card "player", expected one of summary, summary_large_image`)
+}
@@ -0,0 +1,247 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openware/pkg/database"
+	"gorm.io/gorm"
+)
+
+func init() {
+	Register("redirects", &Redirect{}, nil)
+}
+
+// RedirectStatuses are the statuses of redirects, permanent, temporary and gone
+var RedirectStatuses = []int{http.StatusMovedPermanently, http.StatusFound, http.StatusGone}
+
+// ErrRedirectTaken is returned when saving a redirect with the source of another one
+var ErrRedirectTaken = errors.New("source is already redirected")
+
+// ErrRedirectChain is returned when saving a redirect to the source of another one, clients would follow
+// both or loop between them
+var ErrRedirectChain = errors.New("target is redirected")
+
+// Redirect sends the requests of Source to Target with Status, or answers 410 Gone without target.
+// Sources ending with /* are patterns matching the paths below them, a * in their target is replaced
+// by the rest of the path, f.e. /blog/* to /news/* redirects /blog/2021/fees to /news/2021/fees.
+// Hits counts the redirected requests.
+type Redirect struct {
+	ID        uint   `gorm:"primarykey"`
+	Source    string `gorm:"size:255;not null;uniqueIndex"`
+	Target    string `gorm:"size:255;not null;default:''"`
+	Status    int    `gorm:"not null;default:301"`
+	Pattern   bool   `gorm:"not null;default:false;index"`
+	Hits      int64  `gorm:"not null;default:0"`
+	LastHitAt *time.Time
+	database.Timestamps
+}
+
+// Validate checks the source, target and status of the redirect
+func (r *Redirect) Validate() error {
+	if !strings.HasPrefix(r.Source, "/") {
+		return fmt.Errorf("invalid source %q, expected an absolute path", r.Source)
+	}
+	if i := strings.Index(r.Source, "*"); i >= 0 && (i != len(r.Source)-1 || !strings.HasSuffix(r.Source, "/*")) {
+		return fmt.Errorf("invalid source %q, * is only allowed at the end after a /", r.Source)
+	}
+	statuses := make([]string, len(RedirectStatuses))
+	valid := false
+	for i, status := range RedirectStatuses {
+		statuses[i] = fmt.Sprint(status)
+		valid = valid || r.Status == status
+	}
+	if !valid {
+		return fmt.Errorf("invalid status %d, expected one of %s", r.Status, strings.Join(statuses, ", "))
+	}
+
+	if r.Status == http.StatusGone {
+		if r.Target != "" {
+			return errors.New("gone redirects have no target")
+		}
+		return nil
+	}
+	switch {
+	case r.Target == "":
+		return errors.New("target is required")
+	case !strings.HasPrefix(r.Target, "/") && !strings.HasPrefix(r.Target, "https://") && !strings.HasPrefix(r.Target, "http://"):
+		return fmt.Errorf("invalid target %q, expected an absolute path or an http(s) URL", r.Target)
+	case strings.Contains(r.Target, "*") && !r.isPattern():
+		return fmt.Errorf("invalid target %q, * is only allowed with a pattern source", r.Target)
+	}
+	// Targets matched by the source would redirect again, forever
+	target := redirectPath(r.Target)
+	switch {
+	case !strings.HasPrefix(target, "/"):
+	case strings.TrimSuffix(target, "/") == strings.TrimSuffix(r.Source, "/"):
+		return errors.New("target is the source")
+	case r.isPattern() && len(target) > len(r.Source)-1 && strings.HasPrefix(target, strings.TrimSuffix(r.Source, "*")):
+		return errors.New("target is matched by the source")
+	}
+	return nil
+}
+
+// redirectPath returns the path of a target without its query and fragment
+func redirectPath(target string) string {
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		return target[:i]
+	}
+	return target
+}
+
+func (r *Redirect) isPattern() bool {
+	return strings.HasSuffix(r.Source, "/*")
+}
+
+// BeforeSave sets the default status and the pattern flag, then validates the redirect
+func (r *Redirect) BeforeSave(tx *gorm.DB) error {
+	if r.Status == 0 {
+		r.Status = http.StatusMovedPermanently
+	}
+	r.Pattern = r.isPattern()
+	return r.Validate()
+}
+
+// Location returns the target of the redirect for path, patterns fill the * of their target with the rest of path.
+// Leading slashes, backslashes and control characters of the rest are dropped,
+// so a target like /* can't become a protocol-relative URL to another host.
+func (r *Redirect) Location(path string) string {
+	if !r.Pattern {
+		return r.Target
+	}
+	rest := strings.TrimPrefix(path, strings.TrimSuffix(r.Source, "*"))
+	rest = strings.TrimLeftFunc(rest, func(c rune) bool {
+		return c == '/' || c == '\\' || c <= ' '
+	})
+	return strings.Replace(r.Target, "*", rest, 1)
+}
+
+// Hit counts a redirected request, the columns are updated without hooks or timestamps
+func (r *Redirect) Hit(t time.Time) error {
+	return db.Model(r).UpdateColumns(map[string]interface{}{
+		"hits":        gorm.Expr("hits + 1"),
+		"last_hit_at": t,
+	}).Error
+}
+
+// FindRedirect returns the redirect of path, the one with this source or the longest pattern matching it,
+// nil if there is none
+func FindRedirect(path string) (*Redirect, error) {
+	return findRedirect(db, path, 0)
+}
+
+// findRedirect returns the redirect of path other than the one with id except
+func findRedirect(tx *gorm.DB, path string, except uint) (*Redirect, error) {
+	redirect := Redirect{}
+	err := tx.Where("source = ? AND pattern = ? AND id <> ?", path, false, except).First(&redirect).Error
+	if err == nil {
+		return &redirect, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	patterns := []Redirect{}
+	if err := tx.Where("pattern = ? AND id <> ?", true, except).Find(&patterns).Error; err != nil {
+		return nil, err
+	}
+	var found *Redirect
+	for i := range patterns {
+		prefix := strings.TrimSuffix(patterns[i].Source, "*")
+		if len(path) > len(prefix) && strings.HasPrefix(path, prefix) && (found == nil || len(patterns[i].Source) > len(found.Source)) {
+			found = &patterns[i]
+		}
+	}
+	return found, nil
+}
+
+// chainedTo returns the other redirect matching the target of r, the * of pattern targets stands for
+// any path so only the patterns matching all of them are returned
+func (r *Redirect) chainedTo(tx *gorm.DB) (*Redirect, error) {
+	target := redirectPath(r.Target)
+	if r.Status == http.StatusGone || !strings.HasPrefix(target, "/") {
+		return nil, nil
+	}
+	found, err := findRedirect(tx, target, r.ID)
+	if found != nil || err != nil || strings.HasSuffix(target, "*") {
+		return found, err
+	}
+	// A trailing slash doesn't make another page
+	if alt := strings.TrimSuffix(target, "/"); alt != target && alt != "" {
+		return findRedirect(tx, alt, r.ID)
+	}
+	return findRedirect(tx, target+"/", r.ID)
+}
+
+// ListRedirects returns a page of the redirects by source and the number of redirects
+func ListRedirects(offset, limit int) ([]Redirect, int64, error) {
+	var total int64
+	if err := db.Model(&Redirect{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	redirects := []Redirect{}
+	err := db.Order("source").Offset(offset).Limit(limit).Find(&redirects).Error
+	return redirects, total, err
+}
+
+// GetRedirect returns the redirect with this id, nil if there is none
+func GetRedirect(id uint) (*Redirect, error) {
+	redirect := Redirect{}
+	if err := db.First(&redirect, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &redirect, nil
+}
+
+// SaveRedirect creates or updates a redirect, ErrRedirectTaken is returned if another redirect has its source
+// and ErrRedirectChain if another redirect has its target as source
+func SaveRedirect(redirect *Redirect) error {
+	var count int64
+	if err := db.Model(&Redirect{}).Where("source = ? AND id <> ?", redirect.Source, redirect.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRedirectTaken
+	}
+	chained, err := redirect.chainedTo(db)
+	if err != nil {
+		return err
+	}
+	if chained != nil {
+		return fmt.Errorf("%w by %s", ErrRedirectChain, chained.Source)
+	}
+	if redirect.ID == 0 {
+		return db.Create(redirect).Error
+	}
+	return db.Save(redirect).Error
+}
+
+// DeleteRedirect removes the redirect with this id and tells if it existed
+func DeleteRedirect(id uint) (bool, error) {
+	deleted := db.Delete(&Redirect{}, id)
+	return deleted.RowsAffected > 0, deleted.Error
+}
+
+// redirectMovedPage redirects the old path of a page to its new one. The older redirects to the old path
+// are rewritten to the new one so moves don't build chains, and the ones of the new path are removed since a page is there.
+func redirectMovedPage(tx *gorm.DB, from, to string) error {
+	if err := tx.Where("source = ?", to).Delete(&Redirect{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&Redirect{}).Where("target IN ? AND source <> ?", []string{from, from + "/"}, to).UpdateColumn("target", to).Error; err != nil {
+		return err
+	}
+	redirect := Redirect{}
+	err := tx.Where("source = ?", from).First(&redirect).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	redirect.Source, redirect.Target, redirect.Status = from, to, http.StatusMovedPermanently
+	return tx.Save(&redirect).Error
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectValidate(t *testing.T) {
+	tests := []struct {
+		redirect Redirect
+		err      string
+	}{
+		{Redirect{Source: "/fees", Target: "/pricing", Status: http.StatusMovedPermanently}, ""},
+		{Redirect{Source: "/blog/*", Target: "/news/*", Status: http.StatusFound}, ""},
+		{Redirect{Source: "/old", Target: "https://blog.openware.com", Status: http.StatusFound}, ""},
+		{Redirect{Source: "/listing", Status: http.StatusGone}, ""},
+		{Redirect{Source: "fees", Target: "/pricing", Status: http.StatusMovedPermanently}, `invalid source "fees", expected an absolute path`},
+		{Redirect{Source: "/blog*", Target: "/news", Status: http.StatusMovedPermanently}, `invalid source "/blog*", * is only allowed at the end after a /`},
+		{Redirect{Source: "/fees", Target: "/pricing", Status: http.StatusTemporaryRedirect}, "invalid status 307, expected one of 301, 302, 410"},
+		{Redirect{Source: "/listing", Target: "/markets", Status: http.StatusGone}, "gone redirects have no target"},
+		{Redirect{Source: "/fees", Status: http.StatusMovedPermanently}, "target is required"},
+		{Redirect{Source: "/fees", Target: "ftp://fees", Status: http.StatusMovedPermanently}, `invalid target "ftp://fees", expected an absolute path or an http(s) URL`},
+		{Redirect{Source: "/fees", Target: "/pricing/*", Status: http.StatusMovedPermanently}, `invalid target "/pricing/*", * is only allowed with a pattern source`},
+		{Redirect{Source: "/fees", Target: "/fees", Status: http.StatusMovedPermanently}, "target is the source"},
+		{Redirect{Source: "/fees", Target: "/fees/?utm=mail", Status: http.StatusMovedPermanently}, "target is the source"},
+		{Redirect{Source: "/blog/*", Target: "/blog/*", Status: http.StatusMovedPermanently}, "target is the source"},
+		{Redirect{Source: "/blog/*", Target: "/blog/news/*", Status: http.StatusMovedPermanently}, "target is matched by the source"},
+		{Redirect{Source: "/blog/*", Target: "/blog/index", Status: http.StatusMovedPermanently}, "target is matched by the source"},
+		{Redirect{Source: "/blog/*", Target: "/blog", Status: http.StatusMovedPermanently}, ""},
+	}
+	for _, tt := range tests {
+		err := tt.redirect.Validate()
+		if tt.err == "" {
+			assert.NoError(t, err, tt.redirect.Source)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestFindRedirect(t *testing.T) {
+	InitTestDB()
+	require.NoError(t, SaveRedirect(&Redirect{Source: "/blog/*", Target: "/news/*"}))
+	require.NoError(t, SaveRedirect(&Redirect{Source: "/blog/2021/*", Target: "/archive"}))
+	require.NoError(t, SaveRedirect(&Redirect{Source: "/blog/fees", Target: "/fees", Status: http.StatusFound}))
+	assert.Equal(t, ErrRedirectTaken, SaveRedirect(&Redirect{Source: "/blog/fees", Target: "/pricing"}))
+	assert.EqualError(t, SaveRedirect(&Redirect{Source: "/fees", Target: "/blog/fees"}), "target is redirected by /blog/fees")
+	assert.EqualError(t, SaveRedirect(&Redirect{Source: "/pricing", Target: "/blog/listing/"}), "target is redirected by /blog/*")
+	assert.EqualError(t, SaveRedirect(&Redirect{Source: "/articles/*", Target: "/blog/*"}), "target is redirected by /blog/*")
+	assert.ErrorIs(t, SaveRedirect(&Redirect{Source: "/fees", Target: "/blog/fees/"}), ErrRedirectChain)
+	assert.NoError(t, SaveRedirect(&Redirect{Source: "/articles/*", Target: "/news/*"}))
+
+	tests := []struct {
+		path     string
+		location string
+	}{
+		{"/blog/fees", "/fees"},
+		{"/blog/listing/rules", "/news/listing/rules"},
+		{"/articles//evil.example", "/news/evil.example"},
+		{"/blog/2021/fees", "/archive"},
+		{"/blog/", ""},
+		{"/blog", ""},
+		{"/news", ""},
+	}
+	for _, tt := range tests {
+		redirect, err := FindRedirect(tt.path)
+		require.NoError(t, err)
+		if tt.location == "" {
+			assert.Nil(t, redirect, tt.path)
+			continue
+		}
+		require.NotNil(t, redirect, tt.path)
+		assert.Equal(t, tt.location, redirect.Location(tt.path))
+	}
+
+	redirect, err := FindRedirect("/blog/fees")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, redirect.Status)
+	assert.False(t, redirect.Pattern)
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, redirect.Hit(now))
+	require.NoError(t, redirect.Hit(now))
+	redirect, err = GetRedirect(redirect.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), redirect.Hits)
+	require.NotNil(t, redirect.LastHitAt)
+	assert.True(t, now.Equal(*redirect.LastHitAt))
+
+	found, err := DeleteRedirect(redirect.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	found, err = DeleteRedirect(redirect.ID)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestMovedPageRedirect(t *testing.T) {
+	InitTestDB()
+	page := Page{Path: "/fees", Title: "Fees"}
+	require.NoError(t, SavePage(&page, ""))
+	draft := Page{Path: "/draft", Title: "Draft", Status: PageDraft}
+	require.NoError(t, SavePage(&draft, ""))
+
+	page.Path = "/pricing"
+	require.NoError(t, SavePage(&page, ""))
+	redirect, err := FindRedirect("/fees")
+	require.NoError(t, err)
+	require.NotNil(t, redirect)
+	assert.Equal(t, "/pricing", redirect.Target)
+	assert.Equal(t, http.StatusMovedPermanently, redirect.Status)
+
+	// The first redirect points to the last path, no chain
+	page.Path = "/trading-fees"
+	require.NoError(t, SavePage(&page, ""))
+	redirect, err = FindRedirect("/fees")
+	require.NoError(t, err)
+	assert.Equal(t, "/trading-fees", redirect.Target)
+	redirect, err = FindRedirect("/pricing")
+	require.NoError(t, err)
+	assert.Equal(t, "/trading-fees", redirect.Target)
+
+	// Moving back removes the redirect of the path of the page
+	page.Path = "/fees"
+	require.NoError(t, SavePage(&page, ""))
+	redirect, err = FindRedirect("/fees")
+	require.NoError(t, err)
+	assert.Nil(t, redirect)
+	redirect, err = FindRedirect("/trading-fees")
+	require.NoError(t, err)
+	assert.Equal(t, "/fees", redirect.Target)
+
+	// Drafts were never served
+	draft.Path = "/drafts/fees"
+	require.NoError(t, SavePage(&draft, ""))
+	redirect, err = FindRedirect("/draft")
+	require.NoError(t, err)
+	assert.Nil(t, redirect)
+
+	// Redirects apply to every language, the path stays with the pages in the other languages
+	en := Page{Path: "/terms", Lang: "en", Title: "Terms"}
+	require.NoError(t, SavePage(&en, ""))
+	ru := Page{Path: "/terms", Lang: "ru", Title: "Условия"}
+	require.NoError(t, SavePage(&ru, ""))
+	ru.Path = "/tos"
+	require.NoError(t, SavePage(&ru, ""))
+	redirect, err = FindRedirect("/terms")
+	require.NoError(t, err)
+	assert.Nil(t, redirect)
+
+	en.Path = "/legal/terms"
+	require.NoError(t, SavePage(&en, ""))
+	redirect, err = FindRedirect("/terms")
+	require.NoError(t, err)
+	require.NotNil(t, redirect)
+	assert.Equal(t, "/legal/terms", redirect.Target)
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	Register("page_revisions", &PageRevision{}, nil)
+}
+
+// ErrRevisionImmutable is returned when saving a revision which already exists
+var ErrRevisionImmutable = errors.New("page revisions can't be changed")
+
+// PageRevision is the content of a page saved by the admin API, revisions are never updated
+type PageRevision struct {
+	ID     uint `gorm:"primarykey"`
+	PageID uint `gorm:"uniqueIndex:idx_page_revisions_version;not null"`
+	// Version is the version of the page this revision created
+	Version       uint   `gorm:"uniqueIndex:idx_page_revisions_version;not null"`
+	Author        string `gorm:"size:255"`
+	Path          string `gorm:"size:64;not null"`
+	Lang          string
+	Title         string
+	Description   string
+	Body          string
+	Keywords      string
+	CanonicalURL  string `gorm:"size:255"`
+	OGTitle       string `gorm:"size:255"`
+	OGDescription string
+	OGImage       string `gorm:"size:255"`
+	TwitterCard   string `gorm:"size:32"`
+	NoIndex       bool   `gorm:"not null;default:false"`
+	Format        string `gorm:"size:16;not null"`
+	Status        string `gorm:"size:16;not null"`
+	PublishAt     *time.Time
+	UnpublishAt   *time.Time
+	CreatedAt     time.Time
+}
+
+func newPageRevision(p *Page, author string) *PageRevision {
+	return &PageRevision{
+		PageID:        p.ID,
+		Version:       p.Version,
+		Author:        author,
+		Path:          p.Path,
+		Lang:          p.Lang,
+		Title:         p.Title,
+		Description:   p.Description,
+		Body:          p.Body,
+		Keywords:      p.Keywords,
+		CanonicalURL:  p.CanonicalURL,
+		OGTitle:       p.OGTitle,
+		OGDescription: p.OGDescription,
+		OGImage:       p.OGImage,
+		TwitterCard:   p.TwitterCard,
+		NoIndex:       p.NoIndex,
+		Format:        p.Format,
+		Status:        p.Status,
+		PublishAt:     p.PublishAt,
+		UnpublishAt:   p.UnpublishAt,
+	}
+}
+
+// BeforeUpdate keeps the revisions immutable
+func (r *PageRevision) BeforeUpdate(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+// Text returns the fields and the body of the revision as compared by diffs
+func (r *PageRevision) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "path: %s\nlang: %s\ntitle: %s\ndescription: %s\nformat: %s\n", r.Path, r.Lang, r.Title, r.Description, r.Format)
+	fmt.Fprintf(&b, "keywords: %s\ncanonical_url: %s\nog_title: %s\nog_description: %s\nog_image: %s\ntwitter_card: %s\nnoindex: %t\n",
+		r.Keywords, r.CanonicalURL, r.OGTitle, r.OGDescription, r.OGImage, r.TwitterCard, r.NoIndex)
+	fmt.Fprintf(&b, "status: %s\npublish_at: %s\nunpublish_at: %s\n\n", r.Status, formatTime(r.PublishAt), formatTime(r.UnpublishAt))
+	b.WriteString(r.Body)
+	return b.String()
+}
+
+// Restore sets the content and the metadata of the revision to the page, the status and the schedule of the page are kept
+func (r *PageRevision) Restore(p *Page) {
+	p.Path = r.Path
+	p.Lang = r.Lang
+	p.Title = r.Title
+	p.Description = r.Description
+	p.Body = r.Body
+	p.Keywords = r.Keywords
+	p.CanonicalURL = r.CanonicalURL
+	p.OGTitle = r.OGTitle
+	p.OGDescription = r.OGDescription
+	p.OGImage = r.OGImage
+	p.TwitterCard = r.TwitterCard
+	p.NoIndex = r.NoIndex
+	p.Format = r.Format
+}
+
+// ListPageRevisions returns the revisions of a page, the latest first
+func ListPageRevisions(pageID uint) ([]PageRevision, error) {
+	revisions := []PageRevision{}
+	err := db.Where("page_id = ?", pageID).Order("version DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+// FindPageRevision returns the revision of a page created by version, or nil if there is none
+func FindPageRevision(pageID, version uint) (*PageRevision, error) {
+	revision := PageRevision{}
+	err := db.Where("page_id = ? AND version = ?", pageID, version).First(&revision).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
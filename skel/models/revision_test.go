@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageRevision(t *testing.T) {
+	InitTestDB()
+	page := Page{Path: "/faq", Title: "FAQ", Body: "Questions"}
+	require.NoError(t, SavePage(&page, "admin@example.com"))
+	page.Title = "Help"
+	require.NoError(t, SavePage(&page, "editor@example.com"))
+
+	revisions, err := ListPageRevisions(page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Help", revisions[0].Title)
+	assert.Equal(t, "editor@example.com", revisions[0].Author)
+	assert.Equal(t, uint(1), revisions[1].Version)
+
+	first, err := FindPageRevision(page.ID, 1)
+	require.NoError(t, err)
+	first.Title = "Changed"
+	assert.Equal(t, ErrRevisionImmutable, db.Save(first).Error)
+	assert.Contains(t, first.Text(), "path: /faq\n")
+
+	missing, err := FindPageRevision(page.ID, 5)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	found, err := DeletePage(page.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	revisions, err = ListPageRevisions(page.ID)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
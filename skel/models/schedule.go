@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Page statuses
+const (
+	PageDraft     = "draft"
+	PageScheduled = "scheduled"
+	PagePublished = "published"
+	PageArchived  = "archived"
+)
+
+// PageStatuses lists the statuses of pages
+var PageStatuses = []string{PageDraft, PageScheduled, PagePublished, PageArchived}
+
+// Live tells if the page is served at t: published, or scheduled and due, and not yet unpublished
+func (p *Page) Live(t time.Time) bool {
+	switch {
+	case p.Status == PagePublished && (p.PublishAt == nil || !p.PublishAt.After(t)):
+	case p.Status == PageScheduled && p.PublishAt != nil && !p.PublishAt.After(t):
+	default:
+		return false
+	}
+	return p.UnpublishAt == nil || p.UnpublishAt.After(t)
+}
+
+func (p *Page) validateSchedule() error {
+	if !contains(PageStatuses, p.Status) {
+		return fmt.Errorf("invalid page status %q, expected one of %s", p.Status, strings.Join(PageStatuses, ", "))
+	}
+	if p.Status == PageScheduled && p.PublishAt == nil {
+		return errors.New("publish_at is required for scheduled pages")
+	}
+	if p.PublishAt != nil && p.UnpublishAt != nil && !p.UnpublishAt.After(*p.PublishAt) {
+		return errors.New("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
+// live restricts a query to the pages served at t, the scheduling daemon may not have flipped them yet
+func live(tx *gorm.DB, t time.Time) *gorm.DB {
+	return tx.Where("(status = ? AND (publish_at IS NULL OR publish_at <= ?)) OR (status = ? AND publish_at <= ?)", PagePublished, t, PageScheduled, t).
+		Where("unpublish_at IS NULL OR unpublish_at > ?", t)
+}
+
+// ApplySchedule publishes the scheduled pages due at t and archives the published pages expired at t.
+// It returns the number of pages changed.
+func ApplySchedule(t time.Time) (int64, error) {
+	published := db.Model(&Page{}).
+		Where("status = ? AND publish_at <= ?", PageScheduled, t).
+		UpdateColumns(statusColumns(PagePublished, t))
+	if published.Error != nil {
+		return 0, published.Error
+	}
+	archived := db.Model(&Page{}).
+		Where("status IN ? AND unpublish_at <= ?", []string{PagePublished, PageScheduled}, t).
+		UpdateColumns(statusColumns(PageArchived, t))
+	return published.RowsAffected + archived.RowsAffected, archived.Error
+}
+
+// NextScheduleChange returns the time of the next publication or archiving after t, nil if none is planned
+func NextScheduleChange(t time.Time) (*time.Time, error) {
+	publication, err := firstPageAfter("publish_at", []string{PageScheduled}, t)
+	if err != nil {
+		return nil, err
+	}
+	archiving, err := firstPageAfter("unpublish_at", []string{PagePublished, PageScheduled}, t)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case archiving == nil && publication == nil:
+		return nil, nil
+	case archiving == nil:
+		return publication.PublishAt, nil
+	case publication == nil || archiving.UnpublishAt.Before(*publication.PublishAt):
+		return archiving.UnpublishAt, nil
+	}
+	return publication.PublishAt, nil
+}
+
+// firstPageAfter returns the page in one of statuses with the earliest column time after t, nil if there is none
+func firstPageAfter(column string, statuses []string, t time.Time) (*Page, error) {
+	page := Page{}
+	err := db.Where("status IN ? AND "+column+" > ?", statuses, t).Order(column).Take(&page).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// statusColumns changes the status without the hooks, which validate a single page
+func statusColumns(status string, t time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"status":     status,
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": t,
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageSchedule(t *testing.T) {
+	InitTestDB()
+	now := time.Now().Truncate(time.Second)
+	past, soon, later := now.Add(-time.Hour), now.Add(time.Minute), now.Add(time.Hour)
+
+	pages := []Page{
+		{Path: "/draft", Title: "Draft", Status: PageDraft},
+		{Path: "/published", Title: "Published", Status: PagePublished},
+		{Path: "/due", Title: "Due", Status: PageScheduled, PublishAt: &past},
+		{Path: "/scheduled", Title: "Scheduled", Status: PageScheduled, PublishAt: &later},
+		{Path: "/expiring", Title: "Expiring", Status: PagePublished, UnpublishAt: &soon},
+		{Path: "/expired", Title: "Expired", Status: PagePublished, UnpublishAt: &past},
+		{Path: "/archived", Title: "Archived", Status: PageArchived},
+	}
+	for i := range pages {
+		require.NoError(t, db.Create(&pages[i]).Error)
+	}
+
+	p := &Page{}
+	assert.Nil(t, p.FindByPath("/draft"))
+	assert.NotNil(t, p.FindByPath("/published"))
+	assert.NotNil(t, p.FindByPath("/due"), "due pages are live before the daemon publishes them")
+	assert.Nil(t, p.FindByPath("/scheduled"))
+	assert.NotNil(t, p.FindByPath("/expiring"))
+	assert.Nil(t, p.FindByPath("/expired"))
+	assert.Nil(t, p.FindByPath("/archived"))
+	assert.Len(t, p.List(), 3)
+	for _, page := range pages {
+		assert.Equal(t, page.Live(now), p.FindByPath(page.Path) != nil, page.Path)
+	}
+
+	all, total, err := ListPages("", 0, 20)
+	require.NoError(t, err)
+	assert.Len(t, all, len(pages))
+	assert.Equal(t, int64(len(pages)), total)
+
+	next, err := NextScheduleChange(now)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.True(t, next.Equal(soon), next)
+
+	changed, err := ApplySchedule(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), changed)
+	due, err := FindPage(pages[2].ID)
+	require.NoError(t, err)
+	require.NotNil(t, due)
+	assert.Equal(t, PagePublished, due.Status)
+	assert.Equal(t, uint(2), due.Version)
+	expired, err := FindPage(pages[5].ID)
+	require.NoError(t, err)
+	assert.Equal(t, PageArchived, expired.Status)
+
+	changed, err = ApplySchedule(later)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), changed)
+	next, err = NextScheduleChange(later)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestPageScheduleValidation(t *testing.T) {
+	now := time.Now()
+	before := now.Add(-time.Minute)
+	page := Page{Path: "/news", Title: "News", Format: "markdown"}
+
+	page.Status = "hidden"
+	assert.EqualError(t, page.Validate(), `invalid page status "hidden", expected one of draft, scheduled, published, archived`)
+	page.Status = PageScheduled
+	assert.EqualError(t, page.Validate(), "publish_at is required for scheduled pages")
+	page.PublishAt, page.UnpublishAt = &now, &before
+	assert.EqualError(t, page.Validate(), "unpublish_at must be after publish_at")
+	page.UnpublishAt = nil
+	assert.NoError(t, page.Validate())
+}
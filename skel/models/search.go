@@ -0,0 +1,205 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openware/sonic/skel/content"
+	"gorm.io/gorm"
+)
+
+// Weights of the title, description and body of pages in the ranking of search results
+const (
+	searchTitleWeight       = 10
+	searchDescriptionWeight = 5
+	searchBodyWeight        = 1
+)
+
+// searchIndex finds the pages matching search terms, it's chosen by the database when pages are migrated
+type searchIndex interface {
+	// index stores the current content of the page id
+	index(tx *gorm.DB, id uint) error
+	// remove forgets the page id
+	remove(tx *gorm.DB, id uint) error
+	// search returns at most limit pages of the query tx matching all the terms, best matches first
+	search(tx *gorm.DB, terms []string, limit int) ([]Page, error)
+}
+
+// search is the index of pages, scanSearch until the pages are migrated
+var search searchIndex = scanSearch{}
+
+// SearchPages returns the live pages matching all the terms, best matches first.
+// Terms match the words they start, lang restricts the results to the pages in this language or without language.
+func SearchPages(terms []string, lang string, limit int) ([]Page, error) {
+	if len(terms) == 0 {
+		return []Page{}, nil
+	}
+	tx := live(db.Model(&Page{}), time.Now())
+	if lang != "" {
+		tx = tx.Where("lang IN ?", []string{NormalizeLang(lang), ""})
+	}
+	return search.search(tx, terms, limit)
+}
+
+// AfterMigrate sets up the search index of the database
+func (p *Page) AfterMigrate(tx *gorm.DB) error {
+	index, err := setupSearch(tx)
+	if err != nil {
+		return err
+	}
+	search = index
+	return nil
+}
+
+// AfterSave updates the search index, batch updates of columns skip it but they only change statuses
+func (p *Page) AfterSave(tx *gorm.DB) error {
+	return search.index(tx, p.ID)
+}
+
+// setupSearch uses a FULLTEXT index on MySQL and an FTS5 table on SQLite,
+// the pages are scanned when SQLite is built without FTS5 (the sqlite_fts5 build tag)
+func setupSearch(tx *gorm.DB) (searchIndex, error) {
+	switch tx.Dialector.Name() {
+	case "mysql":
+		if !tx.Migrator().HasIndex(&Page{}, "idx_pages_search") {
+			if err := tx.Exec("CREATE FULLTEXT INDEX idx_pages_search ON pages (title, description, body)").Error; err != nil {
+				return nil, err
+			}
+		}
+		return fulltextSearch{}, nil
+	case "sqlite":
+		var fts5 bool
+		if err := tx.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5).Error; err != nil {
+			return nil, err
+		}
+		if !fts5 {
+			log.Printf("WARN: SQLite is built without FTS5, pages are searched without index")
+			return scanSearch{}, nil
+		}
+		if err := tx.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS page_search USING fts5(title, description, body)").Error; err != nil {
+			return nil, err
+		}
+		// The table is rebuilt in case pages were written by a binary without FTS5
+		if err := tx.Exec("DELETE FROM page_search").Error; err != nil {
+			return nil, err
+		}
+		return fts5Search{}, tx.Exec("INSERT INTO page_search (rowid, title, description, body) SELECT id, title, description, body FROM pages").Error
+	}
+	return scanSearch{}, nil
+}
+
+// fts5Search uses the page_search FTS5 table of SQLite, its rows have the ids of the pages
+type fts5Search struct{}
+
+func (fts5Search) index(tx *gorm.DB, id uint) error {
+	if err := tx.Exec("DELETE FROM page_search WHERE rowid = ?", id).Error; err != nil {
+		return err
+	}
+	return tx.Exec("INSERT INTO page_search (rowid, title, description, body) SELECT id, title, description, body FROM pages WHERE id = ?", id).Error
+}
+
+func (fts5Search) remove(tx *gorm.DB, id uint) error {
+	return tx.Exec("DELETE FROM page_search WHERE rowid = ?", id).Error
+}
+
+func (fts5Search) search(tx *gorm.DB, terms []string, limit int) ([]Page, error) {
+	// Terms are letters and digits, quoted prefix queries can't contain FTS5 syntax
+	queries := make([]string, len(terms))
+	for i, term := range terms {
+		queries[i] = `"` + term + `"*`
+	}
+	pages := []Page{}
+	err := tx.Select("pages.*").
+		Joins("JOIN page_search ON page_search.rowid = pages.id").
+		Where("page_search MATCH ?", strings.Join(queries, " ")).
+		Order(fmt.Sprintf("bm25(page_search, %d, %d, %d)", searchTitleWeight, searchDescriptionWeight, searchBodyWeight)).
+		Limit(limit).
+		Find(&pages).Error
+	return pages, err
+}
+
+// fulltextSearch uses the idx_pages_search FULLTEXT index of MySQL, which is maintained by the database.
+// Words shorter than innodb_ft_min_token_size and stopwords are not indexed.
+type fulltextSearch struct{}
+
+func (fulltextSearch) index(tx *gorm.DB, id uint) error {
+	return nil
+}
+
+func (fulltextSearch) remove(tx *gorm.DB, id uint) error {
+	return nil
+}
+
+func (fulltextSearch) search(tx *gorm.DB, terms []string, limit int) ([]Page, error) {
+	queries := make([]string, len(terms))
+	for i, term := range terms {
+		queries[i] = "+" + term + "*"
+	}
+	query := strings.Join(queries, " ")
+	pages := []Page{}
+	err := tx.Select("pages.*, MATCH (title, description, body) AGAINST (? IN BOOLEAN MODE) AS score", query).
+		Where("MATCH (title, description, body) AGAINST (? IN BOOLEAN MODE)", query).
+		Order("score DESC").
+		Limit(limit).
+		Find(&pages).Error
+	return pages, err
+}
+
+// scanSearch matches the text of every page in Go, it needs no index and suits a few hundred pages
+type scanSearch struct{}
+
+func (scanSearch) index(tx *gorm.DB, id uint) error {
+	return nil
+}
+
+func (scanSearch) remove(tx *gorm.DB, id uint) error {
+	return nil
+}
+
+func (scanSearch) search(tx *gorm.DB, terms []string, limit int) ([]Page, error) {
+	pages := []Page{}
+	if err := tx.Order("id").Find(&pages).Error; err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		page  Page
+		score int
+	}
+	matches := []match{}
+	for _, page := range pages {
+		fields := []struct {
+			text   string
+			weight int
+		}{
+			{page.Title, searchTitleWeight},
+			{page.Description, searchDescriptionWeight},
+			{content.Text(page.Format, page.Body), searchBodyWeight},
+		}
+		// Every term has to start a word of one of the fields
+		text, score := []string{}, 0
+		for _, field := range fields {
+			hits, _ := content.Hits(field.text, terms)
+			score += hits * field.weight
+			text = append(text, field.text)
+		}
+		if _, all := content.Hits(strings.Join(text, " "), terms); all {
+			matches = append(matches, match{page, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	results := make([]Page, len(matches))
+	for i := range matches {
+		results[i] = matches[i].page
+	}
+	return results, nil
+}
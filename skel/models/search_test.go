@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pagePaths(pages []Page) []string {
+	paths := make([]string, len(pages))
+	for i := range pages {
+		paths[i] = pages[i].Lang + pages[i].Path
+	}
+	return paths
+}
+
+func TestSearchPages(t *testing.T) {
+	InitTestDB()
+	for _, page := range []Page{
+		{Path: "/fees", Lang: "en", Title: "Fees", Description: "Trading and withdrawal fees", Body: "Maker fees are low"},
+		{Path: "/fees", Lang: "ru", Title: "Комиссии", Body: "Торговые комиссии"},
+		{Path: "/terms", Title: "Terms", Body: "Trading fees may change"},
+		{Path: "/draft", Title: "Fees draft", Status: PageDraft},
+		{Path: "/faq", Title: "FAQ", Body: "How to withdraw?"},
+	} {
+		require.NoError(t, SavePage(&page, ""))
+	}
+
+	pages, err := SearchPages([]string{"fee"}, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en/fees", "/terms"}, pagePaths(pages))
+
+	pages, err = SearchPages([]string{"trad", "fee"}, "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en/fees"}, pagePaths(pages))
+
+	pages, err = SearchPages([]string{"комисс"}, "RU", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ru/fees"}, pagePaths(pages))
+
+	pages, err = SearchPages([]string{"fee"}, "ru", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/terms"}, pagePaths(pages))
+
+	// The index follows the changes of the pages
+	faq := findTestPage(t, "/faq")
+	faq.Body = "Withdrawal fees are listed in the fees page"
+	require.NoError(t, SavePage(faq, ""))
+	terms := findTestPage(t, "/terms")
+	_, err = DeletePage(terms.ID)
+	require.NoError(t, err)
+	pages, err = SearchPages([]string{"fee"}, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en/fees", "/faq"}, pagePaths(pages))
+}
+
+func findTestPage(t *testing.T, path string) *Page {
+	page := &Page{}
+	require.NoError(t, db.Where("path = ?", path).Take(page).Error)
+	return page
+}
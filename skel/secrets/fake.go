@@ -0,0 +1,205 @@
+//go:build fakevault
+// +build fakevault
+
+package secrets
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeVault is an in-memory stand-in for the parts of Vault used by the application:
+// token lookup, transit encryption and the KV v2 secret engine mounted on secret/.
+// It's used by the test profile so the application runs without a Vault server.
+// Encryption is a plain "fake:" prefix, the data is lost when the process exits.
+// kaigara's vault.Service only talks HTTP, so the fake is a loopback server
+// and is only built with the fakevault tag.
+type FakeVault struct {
+	server *httptest.Server
+	mutex  sync.Mutex
+	kv     map[string]*fakeSecret
+	keys   map[string]bool
+}
+
+type fakeSecret struct {
+	versions []fakeVersion
+}
+
+type fakeVersion struct {
+	data         map[string]interface{}
+	createdTime  time.Time
+	deletionTime string
+}
+
+// NewFakeVault starts a fake Vault server listening on a local port
+func NewFakeVault() (*FakeVault, error) {
+	fv := &FakeVault{
+		kv:   map[string]*fakeSecret{},
+		keys: map[string]bool{},
+	}
+	fv.server = httptest.NewServer(http.HandlerFunc(fv.serve))
+	return fv, nil
+}
+
+// Addr returns the address to configure as vault.addr
+func (fv *FakeVault) Addr() string {
+	return fv.server.URL
+}
+
+// Close stops the server
+func (fv *FakeVault) Close() {
+	fv.server.Close()
+}
+
+func (fv *FakeVault) serve(res http.ResponseWriter, req *http.Request) {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v1/")
+	body := map[string]interface{}{}
+	if req.Body != nil {
+		json.NewDecoder(req.Body).Decode(&body)
+	}
+
+	var data interface{}
+	switch {
+	case strings.HasPrefix(path, "auth/token/lookup"):
+		data = map[string]interface{}{"renewable": false}
+	case strings.HasPrefix(path, "transit/keys/"):
+		data = fv.transitKey(req.Method, strings.TrimPrefix(path, "transit/keys/"))
+	case strings.HasPrefix(path, "transit/encrypt/"):
+		plaintext, _ := body["plaintext"].(string)
+		data = map[string]interface{}{"ciphertext": "fake:" + plaintext}
+	case strings.HasPrefix(path, "transit/decrypt/"):
+		ciphertext, _ := body["ciphertext"].(string)
+		data = map[string]interface{}{"plaintext": strings.TrimPrefix(ciphertext, "fake:")}
+	case strings.HasPrefix(path, "secret/data/"):
+		data = fv.secretData(req, strings.TrimPrefix(path, "secret/data/"), body)
+	case strings.HasPrefix(path, "secret/metadata/"):
+		data = fv.secretMetadata(req, strings.TrimPrefix(path, "secret/metadata/"))
+	}
+
+	// The Vault client turns 404 into nil secrets
+	if data == nil {
+		res.WriteHeader(http.StatusNotFound)
+		res.Write([]byte(`{"errors":[]}`))
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(map[string]interface{}{"data": data})
+}
+
+func (fv *FakeVault) transitKey(method, name string) interface{} {
+	if method == http.MethodPost || method == http.MethodPut {
+		fv.keys[name] = true
+	}
+	if !fv.keys[name] {
+		return nil
+	}
+	return map[string]interface{}{"name": name}
+}
+
+func (fv *FakeVault) secretData(req *http.Request, path string, body map[string]interface{}) interface{} {
+	secret := fv.kv[path]
+
+	switch req.Method {
+	case http.MethodPost, http.MethodPut:
+		if secret == nil {
+			secret = &fakeSecret{}
+			fv.kv[path] = secret
+		}
+		data, _ := body["data"].(map[string]interface{})
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		secret.versions = append(secret.versions, fakeVersion{data: data, createdTime: time.Now().UTC()})
+		return secret.metadata(len(secret.versions))
+
+	case http.MethodDelete:
+		if secret == nil {
+			return nil
+		}
+		latest := &secret.versions[len(secret.versions)-1]
+		latest.deletionTime = time.Now().UTC().Format(time.RFC3339Nano)
+		return secret.metadata(len(secret.versions))
+	}
+
+	if secret == nil {
+		return nil
+	}
+	number := len(secret.versions)
+	if v, err := strconv.Atoi(req.URL.Query().Get("version")); err == nil && v > 0 {
+		number = v
+	}
+	if number > len(secret.versions) {
+		return nil
+	}
+	version := secret.versions[number-1]
+	result := map[string]interface{}{
+		"data":     version.data,
+		"metadata": secret.metadata(number),
+	}
+	if version.deletionTime != "" {
+		result["data"] = nil
+	}
+	return result
+}
+
+func (fv *FakeVault) secretMetadata(req *http.Request, path string) interface{} {
+	if req.Method == "LIST" || req.URL.Query().Get("list") == "true" {
+		prefix := strings.TrimSuffix(path, "/") + "/"
+		seen := map[string]bool{}
+		keys := []string{}
+		for key := range fv.kv {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			name := strings.TrimPrefix(key, prefix)
+			if i := strings.Index(name, "/"); i >= 0 {
+				name = name[:i+1]
+			}
+			if !seen[name] {
+				seen[name] = true
+				keys = append(keys, name)
+			}
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		sort.Strings(keys)
+		return map[string]interface{}{"keys": keys}
+	}
+
+	secret := fv.kv[path]
+	if secret == nil {
+		return nil
+	}
+	versions := map[string]interface{}{}
+	for i, version := range secret.versions {
+		versions[strconv.Itoa(i+1)] = map[string]interface{}{
+			"created_time":  version.createdTime.Format(time.RFC3339Nano),
+			"deletion_time": version.deletionTime,
+			"destroyed":     false,
+		}
+	}
+	return map[string]interface{}{
+		"current_version": len(secret.versions),
+		"versions":        versions,
+	}
+}
+
+func (s *fakeSecret) metadata(number int) map[string]interface{} {
+	version := s.versions[number-1]
+	return map[string]interface{}{
+		"version":       number,
+		"created_time":  version.createdTime.Format(time.RFC3339Nano),
+		"deletion_time": version.deletionTime,
+		"destroyed":     false,
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !fakevault
+// +build !fakevault
+
+package secrets
+
+import "errors"
+
+// ErrFakeVaultDisabled is returned by NewFakeVault in the builds without the fakevault tag
+var ErrFakeVaultDisabled = errors.New("the fake secrets driver needs a build with -tags fakevault")
+
+// FakeVault is the in-memory Vault of the fakevault builds, it's left out of the other ones
+// so the binary doesn't carry a test server
+type FakeVault struct{}
+
+// NewFakeVault returns ErrFakeVaultDisabled
+func NewFakeVault() (*FakeVault, error) {
+	return nil, ErrFakeVaultDisabled
+}
+
+// Addr returns the address to configure as vault.addr
+func (fv *FakeVault) Addr() string {
+	return ""
+}
+
+// Close stops the server
+func (fv *FakeVault) Close() {}
@@ -0,0 +1,53 @@
+//go:build fakevault
+// +build fakevault
+
+package secrets
+
+import (
+	"testing"
+
+	"github.com/openware/kaigara/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeVault(t *testing.T) {
+	fake, err := NewFakeVault()
+	require.NoError(t, err)
+	defer fake.Close()
+
+	service := vault.NewService(fake.Addr(), "fake", "opendax_uat")
+	require.NoError(t, service.LoadSecrets("global", "public"))
+	require.NoError(t, service.SetSecret("global", "title", "Sonic", "public"))
+	require.NoError(t, service.SaveSecrets("global", "public"))
+	require.NoError(t, service.SetSecret("global", "title", "Opendax", "public"))
+	require.NoError(t, service.SaveSecrets("global", "public"))
+
+	require.NoError(t, service.LoadSecrets("global", "secret"))
+	require.NoError(t, service.SetSecret("global", "api_key", "changeme", "secret"))
+	value, err := service.GetSecret("global", "api_key", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "changeme", value)
+
+	apps, err := service.ListAppNames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"global"}, apps)
+
+	store, err := NewStore(fake.Addr(), "fake", "opendax_uat")
+	require.NoError(t, err)
+	versions, err := store.ListVersions("global", "public")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.True(t, versions[0].Current)
+
+	version, err := store.Rollback("global", "public", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+
+	data, err := store.ReadVersion("global", "public", 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"title": "Sonic"}, data)
+
+	_, err = store.ReadVersion("global", "public", 5)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,7 @@
+package secrets
+
+import "sync"
+
+// VaultLock serializes the use of the kaigara vault.Service shared by the handlers and the daemons,
+// it keeps the secrets it loads in maps which are not safe for concurrent use
+var VaultLock sync.Mutex
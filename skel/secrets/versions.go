@@ -0,0 +1,230 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Change types returned by Diff
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// maskedValue replaces values of the "secret" scope in diffs
+const maskedValue = "******"
+
+// ErrNotFound is returned when a version does not exist or has no data anymore
+var ErrNotFound = errors.New("secret version not found")
+
+// Store gives access to the version history kept by Vault KV v2 for every component scope.
+// kaigara's vault.Service only reads the latest version, so Store talks to the Vault API
+// directly using the same layout: secret/{data,metadata}/<deploymentID>/<component>/<scope>
+type Store struct {
+	client       *api.Client
+	deploymentID string
+}
+
+// Version describes a single version of a component scope
+type Version struct {
+	Version      int64     `json:"version"`
+	CreatedTime  time.Time `json:"created_time"`
+	DeletionTime string    `json:"deletion_time,omitempty"`
+	Destroyed    bool      `json:"destroyed"`
+	Current      bool      `json:"current"`
+}
+
+// Change describes the difference of a single key between two versions
+type Change struct {
+	Key  string      `json:"key"`
+	Type string      `json:"type"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// NewStore creates a Store connected to the Vault server
+func NewStore(addr, token, deploymentID string) (*Store, error) {
+	if deploymentID == "" {
+		return nil, fmt.Errorf("deployment ID is missing")
+	}
+
+	client, err := api.NewClient(&api.Config{
+		Address: addr,
+		Timeout: time.Second * 2,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(token)
+
+	return &Store{
+		client:       client,
+		deploymentID: deploymentID,
+	}, nil
+}
+
+func (s *Store) path(directory, appName, scope string) string {
+	return fmt.Sprintf("secret/%s/%s/%s/%s", directory, s.deploymentID, appName, scope)
+}
+
+// ListVersions returns all the versions of a component scope, latest first
+func (s *Store) ListVersions(appName, scope string) ([]Version, error) {
+	secret, err := s.client.Logical().Read(s.path("metadata", appName, scope))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return []Version{}, nil
+	}
+
+	current, err := toInt64(secret.Data["current_version"])
+	if err != nil {
+		return nil, err
+	}
+
+	raw, _ := secret.Data["versions"].(map[string]interface{})
+	versions := make([]Version, 0, len(raw))
+	for key, val := range raw {
+		number, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected version number %q: %s", key, err)
+		}
+		meta, _ := val.(map[string]interface{})
+
+		v := Version{
+			Version: number,
+			Current: number == current,
+		}
+		if created, ok := meta["created_time"].(string); ok {
+			v.CreatedTime, _ = time.Parse(time.RFC3339Nano, created)
+		}
+		v.DeletionTime, _ = meta["deletion_time"].(string)
+		v.Destroyed, _ = meta["destroyed"].(bool)
+		versions = append(versions, v)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+	return versions, nil
+}
+
+// ReadVersion returns the data of a component scope at the given version.
+// Version 0 is the latest one.
+func (s *Store) ReadVersion(appName, scope string, version int64) (map[string]interface{}, error) {
+	params := map[string][]string{}
+	if version > 0 {
+		params["version"] = []string{strconv.FormatInt(version, 10)}
+	}
+
+	secret, err := s.client.Logical().ReadWithData(s.path("data", appName, scope), params)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("version %d of %s.%s: %w", version, appName, scope, ErrNotFound)
+	}
+
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("version %d of %s.%s is deleted or destroyed: %w", version, appName, scope, ErrNotFound)
+	}
+	return data, nil
+}
+
+// Diff compares two versions of a component scope.
+// Values of the "secret" scope are encrypted and never returned.
+func (s *Store) Diff(appName, scope string, from, to int64) ([]Change, error) {
+	fromData, err := s.ReadVersion(appName, scope, from)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := s.ReadVersion(appName, scope, to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := Diff(fromData, toData)
+	if scope == "secret" {
+		for i := range changes {
+			if changes[i].From != nil {
+				changes[i].From = maskedValue
+			}
+			if changes[i].To != nil {
+				changes[i].To = maskedValue
+			}
+		}
+	}
+	return changes, nil
+}
+
+// Rollback writes the data of the given version as a new version and returns its number,
+// this way the rollback itself stays in the history and can be undone too
+func (s *Store) Rollback(appName, scope string, version int64) (int64, error) {
+	if version <= 0 {
+		return 0, fmt.Errorf("invalid version %d", version)
+	}
+
+	data, err := s.ReadVersion(appName, scope, version)
+	if err != nil {
+		return 0, err
+	}
+
+	secret, err := s.client.Logical().Write(s.path("data", appName, scope), map[string]interface{}{
+		"data": data,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if secret == nil || secret.Data == nil {
+		return 0, nil
+	}
+	return toInt64(secret.Data["version"])
+}
+
+// Diff returns the list of changes needed to go from one set of values to another, sorted by key
+func Diff(from, to map[string]interface{}) []Change {
+	changes := []Change{}
+
+	for key, fromVal := range from {
+		toVal, ok := to[key]
+		if !ok {
+			changes = append(changes, Change{Key: key, Type: ChangeRemoved, From: fromVal})
+		} else if !reflect.DeepEqual(fromVal, toVal) {
+			changes = append(changes, Change{Key: key, Type: ChangeChanged, From: fromVal, To: toVal})
+		}
+	}
+	for key, toVal := range to {
+		if _, ok := from[key]; !ok {
+			changes = append(changes, Change{Key: key, Type: ChangeAdded, To: toVal})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case json.Number:
+		return n.Int64()
+	case float64:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	default:
+		return 0, fmt.Errorf("unexpected version type %T", v)
+	}
+}
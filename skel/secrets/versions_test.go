@@ -0,0 +1,125 @@
+package secrets
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	metadataPath = "/v1/secret/metadata/opendax_uat/global/public"
+	dataPath     = "/v1/secret/data/opendax_uat/global/public"
+)
+
+// newVaultMock serves a KV v2 scope with two versions and records written data
+func newVaultMock(t *testing.T, written *map[string]interface{}) *httptest.Server {
+	versions := map[string]map[string]interface{}{
+		"1": {"title": "Sonic", "theme": "dark"},
+		"2": {"title": "Opendax", "logo": "logo.png"},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(metadataPath, func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(`{"data":{"current_version":2,"versions":{
+			"1":{"created_time":"2021-06-01T10:00:00.000000Z","deletion_time":"","destroyed":false},
+			"2":{"created_time":"2021-06-02T10:00:00.000000Z","deletion_time":"","destroyed":false}}}}`))
+	})
+	mux.HandleFunc(dataPath, func(res http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPut || req.Method == http.MethodPost {
+			body, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			payload := map[string]map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(body, &payload))
+			*written = payload["data"]
+			res.Write([]byte(`{"data":{"version":3}}`))
+			return
+		}
+
+		version := req.URL.Query().Get("version")
+		if version == "" {
+			version = "2"
+		}
+		data, ok := versions[version]
+		if !ok {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := json.Marshal(map[string]interface{}{
+			"data": map[string]interface{}{"data": data},
+		})
+		require.NoError(t, err)
+		res.Write(body)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestListVersions(t *testing.T) {
+	var written map[string]interface{}
+	ts := newVaultMock(t, &written)
+	defer ts.Close()
+
+	store, err := NewStore(ts.URL, "changeme", "opendax_uat")
+	require.NoError(t, err)
+
+	versions, err := store.ListVersions("global", "public")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	assert.Equal(t, int64(2), versions[0].Version)
+	assert.True(t, versions[0].Current)
+	assert.Equal(t, int64(1), versions[1].Version)
+	assert.False(t, versions[1].Current)
+	assert.Equal(t, 2021, versions[1].CreatedTime.Year())
+}
+
+func TestStoreDiff(t *testing.T) {
+	var written map[string]interface{}
+	ts := newVaultMock(t, &written)
+	defer ts.Close()
+
+	store, err := NewStore(ts.URL, "changeme", "opendax_uat")
+	require.NoError(t, err)
+
+	changes, err := store.Diff("global", "public", 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Key: "logo", Type: ChangeAdded, To: "logo.png"},
+		{Key: "theme", Type: ChangeRemoved, From: "dark"},
+		{Key: "title", Type: ChangeChanged, From: "Sonic", To: "Opendax"},
+	}, changes)
+
+	_, err = store.Diff("global", "public", 5, 0)
+	assert.Error(t, err)
+}
+
+func TestRollback(t *testing.T) {
+	var written map[string]interface{}
+	ts := newVaultMock(t, &written)
+	defer ts.Close()
+
+	store, err := NewStore(ts.URL, "changeme", "opendax_uat")
+	require.NoError(t, err)
+
+	version, err := store.Rollback("global", "public", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+	assert.Equal(t, map[string]interface{}{"title": "Sonic", "theme": "dark"}, written)
+
+	_, err = store.Rollback("global", "public", 0)
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	changes := Diff(
+		map[string]interface{}{"a": "1", "b": "2"},
+		map[string]interface{}{"a": "1", "b": "3"},
+	)
+	assert.Equal(t, []Change{{Key: "b", Type: ChangeChanged, From: "2", To: "3"}}, changes)
+	assert.Empty(t, Diff(map[string]interface{}{}, map[string]interface{}{}))
+}
@@ -0,0 +1,76 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Log levels, messages are classified by their "DEBUG:", "WARN:" and "ERR:" prefixes,
+// messages without prefix are info
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+var logLevels = map[string]int32{
+	LogDebug: 0,
+	LogInfo:  1,
+	LogWarn:  2,
+	LogError: 3,
+}
+
+var logPrefixes = []struct {
+	prefix []byte
+	level  int32
+}{
+	{[]byte("DEBUG:"), 0},
+	{[]byte("WARN:"), 2},
+	{[]byte("ERR:"), 3},
+}
+
+// LogWriter drops the log messages below the configured level,
+// it's meant to be given to log.SetOutput
+type LogWriter struct {
+	out   io.Writer
+	level int32
+}
+
+// NewLogWriter returns a LogWriter writing to out at info level
+func NewLogWriter(out io.Writer) *LogWriter {
+	return &LogWriter{out: out, level: logLevels[LogInfo]}
+}
+
+// SetLevel changes the minimum level, it's safe to call while logging
+func (w *LogWriter) SetLevel(level string) error {
+	l, ok := logLevels[level]
+	if !ok {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+	atomic.StoreInt32(&w.level, l)
+	return nil
+}
+
+func (w *LogWriter) Write(p []byte) (int, error) {
+	if messageLevel(p) < atomic.LoadInt32(&w.level) {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// messageLevel looks for a level prefix right after the date and time written by the logger
+func messageLevel(p []byte) int32 {
+	head := p
+	if len(head) > 32 {
+		head = head[:32]
+	}
+	for _, lp := range logPrefixes {
+		if bytes.Contains(head, lp.prefix) {
+			return lp.level
+		}
+	}
+	return logLevels[LogInfo]
+}
@@ -0,0 +1,186 @@
+package settings
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay groups the events of a single save, editors often write a file in several steps
+const reloadDelay = 200 * time.Millisecond
+
+// Reloader keeps the current configuration and replaces it when the config files change.
+// A configuration is never modified once published, readers get it with Current
+// and subscribers are notified after every swap.
+type Reloader struct {
+	current     atomic.Value
+	load        func() (*Config, error)
+	validate    func(cfg *Config) []error
+	mutex       sync.Mutex
+	subscribers []func(cfg *Config)
+}
+
+// NewReloader creates a Reloader publishing cfg, load reads a new configuration from the sources
+// and validate returns its problems
+func NewReloader(cfg *Config, load func() (*Config, error), validate func(cfg *Config) []error) *Reloader {
+	r := &Reloader{
+		load:     load,
+		validate: validate,
+	}
+	r.current.Store(cfg)
+	return r
+}
+
+// Current returns the configuration in use, it must not be modified
+func (r *Reloader) Current() *Config {
+	return r.current.Load().(*Config)
+}
+
+// Subscribe registers fn to be called with the new configuration after each reload,
+// fn is called synchronously and should not block
+func (r *Reloader) Subscribe(fn func(cfg *Config)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload reads and validates the configuration, then swaps it and notifies the subscribers.
+// The current configuration is kept if the new one is invalid or changes settings
+// which need a restart, like the port or the database.
+func (r *Reloader) Reload() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cfg, err := r.load()
+	if err != nil {
+		return err
+	}
+	if errs := r.validate(cfg); len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %v", errs)
+	}
+	if changed := RestartRequired(r.Current(), cfg); len(changed) > 0 {
+		return fmt.Errorf("changes of %v require a restart", changed)
+	}
+
+	r.current.Store(cfg)
+	for _, fn := range r.subscribers {
+		fn(cfg)
+	}
+	return nil
+}
+
+// RestartRequired returns the settings which can't be changed by a reload
+func RestartRequired(old, cfg *Config) []string {
+	changed := []string{}
+	if old.Port != cfg.Port {
+		changed = append(changed, "port")
+	}
+	if !reflect.DeepEqual(old.Database, cfg.Database) {
+		changed = append(changed, "database")
+	}
+	// Clients of these services are created on startup
+	if old.Redis != cfg.Redis {
+		changed = append(changed, "redis")
+	}
+	if old.Vault != cfg.Vault || old.DeploymentID != cfg.DeploymentID {
+		changed = append(changed, "vault")
+	}
+	if old.MngAPI != cfg.MngAPI {
+		changed = append(changed, "mngapi")
+	}
+	if old.Env != cfg.Env {
+		changed = append(changed, "env")
+	}
+	if old.Secrets != cfg.Secrets {
+		changed = append(changed, "secrets")
+	}
+	if old.Daemons.Enabled != cfg.Daemons.Enabled {
+		changed = append(changed, "daemons.enabled")
+	}
+	if old.Embed != cfg.Embed {
+		changed = append(changed, "embed")
+	}
+	return changed
+}
+
+// Watch reloads the configuration when one of the files is written or when the process receives SIGHUP,
+// until stop is called. Files which don't exist yet are watched too.
+func (r *Reloader) Watch(files ...string) (stop func(), err error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Directories are watched since editors and ConfigMaps replace files instead of writing them
+	watched := map[string]bool{}
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+		watched[path] = true
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		var pending <-chan time.Time
+		for {
+			select {
+			case <-done:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				path, _ := filepath.Abs(event.Name)
+				if watched[path] && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					pending = time.After(reloadDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("ERR: config watcher: %s", err)
+			case <-hup:
+				r.logReload("SIGHUP")
+			case <-pending:
+				pending = nil
+				r.logReload("file change")
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(hup)
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
+
+func (r *Reloader) logReload(reason string) {
+	if err := r.Reload(); err != nil {
+		log.Printf("ERR: config reload on %s: %s", reason, err)
+		return
+	}
+	log.Printf("Configuration reloaded on %s", reason)
+}
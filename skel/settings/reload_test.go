@@ -0,0 +1,135 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settings")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "app.yml")
+	write := func(content string) {
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+	load := func() (*Config, error) {
+		cfg := &Config{}
+		_, err := Load(cfg, file, Development, nil)
+		return cfg, err
+	}
+	validate := func(cfg *Config) []error {
+		if cfg.Log.Level == "verbose" {
+			return []error{fmt.Errorf("log.level: invalid")}
+		}
+		return nil
+	}
+
+	write("port: 6969\nopendax:\n  addr: http://opendax:6969\n")
+	cfg, err := load()
+	require.NoError(t, err)
+
+	reloader := NewReloader(cfg, load, validate)
+	notified := []*Config{}
+	reloader.Subscribe(func(cfg *Config) {
+		notified = append(notified, cfg)
+	})
+
+	write("port: 6969\nopendax:\n  addr: http://opendax:8080\nlog:\n  level: debug\n")
+	require.NoError(t, reloader.Reload())
+	assert.Equal(t, "http://opendax:8080", reloader.Current().Opendax.Addr)
+	assert.Equal(t, LogDebug, reloader.Current().Log.Level)
+	assert.Equal(t, "http://opendax:6969", cfg.Opendax.Addr)
+	require.Len(t, notified, 1)
+	assert.Equal(t, reloader.Current(), notified[0])
+
+	write("port: 8080\nopendax:\n  addr: http://opendax:9090\n")
+	err = reloader.Reload()
+	assert.EqualError(t, err, "changes of [port] require a restart")
+	assert.Equal(t, "http://opendax:8080", reloader.Current().Opendax.Addr)
+
+	write("port: 6969\nlog:\n  level: verbose\n")
+	assert.Error(t, reloader.Reload())
+
+	write("port: [6969\n")
+	assert.Error(t, reloader.Reload())
+	assert.Len(t, notified, 1)
+}
+
+func TestRestartRequired(t *testing.T) {
+	old := &Config{}
+	old.Port = "6969"
+	old.Database.Host = "localhost"
+
+	cfg := *old
+	cfg.Opendax.Addr = "http://opendax:8080"
+	cfg.Log.Level = LogWarn
+	assert.Empty(t, RestartRequired(old, &cfg))
+
+	cfg.Database.Host = "db"
+	cfg.Vault.Token = "changeme"
+	cfg.Daemons.Enabled = true
+	assert.Equal(t, []string{"database", "vault", "daemons.enabled"}, RestartRequired(old, &cfg))
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settings")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "app.yml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("opendax:\n  addr: http://opendax:6969\n"), 0644))
+	load := func() (*Config, error) {
+		cfg := &Config{}
+		_, err := Load(cfg, file, Development, nil)
+		return cfg, err
+	}
+	cfg, err := load()
+	require.NoError(t, err)
+
+	reloader := NewReloader(cfg, load, func(*Config) []error { return nil })
+	reloaded := make(chan *Config, 1)
+	reloader.Subscribe(func(cfg *Config) {
+		reloaded <- cfg
+	})
+	stop, err := reloader.Watch(file)
+	require.NoError(t, err)
+	defer stop()
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("opendax:\n  addr: http://opendax:8080\n"), 0644))
+	select {
+	case cfg := <-reloaded:
+		assert.Equal(t, "http://opendax:8080", cfg.Opendax.Addr)
+	case <-time.After(3 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := log.New(NewLogWriter(out), "", log.LstdFlags)
+	writer := logger.Writer().(*LogWriter)
+
+	logger.Println("DEBUG: hidden")
+	logger.Println("started")
+	assert.NotContains(t, out.String(), "hidden")
+	assert.Contains(t, out.String(), "started")
+
+	require.NoError(t, writer.SetLevel(LogError))
+	logger.Println("WARN: hidden")
+	logger.Println("ERR: failed")
+	assert.NotContains(t, out.String(), "hidden")
+	assert.Contains(t, out.String(), "ERR: failed")
+
+	assert.Error(t, writer.SetLevel("verbose"))
+}
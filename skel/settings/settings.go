@@ -0,0 +1,195 @@
+package settings
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openware/pkg/sonic/config"
+	"github.com/openware/sonic/skel/secrets"
+	"github.com/openware/sonic/skel/sources"
+)
+
+// Environments
+const (
+	Development = "development"
+	Test        = "test"
+	Production  = "production"
+)
+
+// Secret store drivers
+const (
+	SecretsVault = "vault"
+	SecretsFake  = "fake"
+)
+
+// Media storage drivers
+const (
+	MediaLocal = "local"
+)
+
+// EnvVariable selects the environment when the -env flag is not given
+const EnvVariable = "SONIC_ENV"
+
+// Config extends the sonic configuration with the settings of this application
+type Config struct {
+	config.Config `yaml:",inline"`
+	Env           string          `yaml:"env"`
+	Secrets       SecretsConfig   `yaml:"secrets"`
+	Daemons       DaemonsConfig   `yaml:"daemons"`
+	Log           LogConfig       `yaml:"log"`
+	Asset         AssetConfig     `yaml:"asset"`
+	Routes        RoutesConfig    `yaml:"routes"`
+	Pages         PagesConfig     `yaml:"pages"`
+	Languages     LanguagesConfig `yaml:"languages"`
+	SEO           SEOConfig       `yaml:"seo"`
+	Media         MediaConfig     `yaml:"media"`
+	// Embed serves the views, seeds and public files compiled in the binary instead of the working directory
+	Embed bool `yaml:"embed" env-default:"true"`
+}
+
+// SecretsConfig selects the secret store, "fake" runs an in-memory Vault
+type SecretsConfig struct {
+	Driver string `yaml:"driver" env-default:"vault"`
+}
+
+// DaemonsConfig toggles the background jobs started by serve
+type DaemonsConfig struct {
+	Enabled      bool          `yaml:"enabled" env-default:"true"`
+	SyncInterval time.Duration `yaml:"sync_interval" env-default:"5m"`
+}
+
+// AssetConfig lists the shell commands building the frontend.
+// Init and Build run in Dir, Copy runs in the project root and moves the build into Output.
+// Entries are the names of the manifest files loaded by the index page, in order.
+type AssetConfig struct {
+	Init    string   `yaml:"init"`
+	Build   string   `yaml:"build"`
+	Copy    string   `yaml:"copy"`
+	Dir     string   `yaml:"dir" env-default:"client"`
+	Output  string   `yaml:"output" env-default:"public/assets"`
+	Entries []string `yaml:"entries"`
+}
+
+// RoutesConfig sets how paths without route are answered, by prefix.
+// API paths get a JSON 404 and client paths the index page of the single page application,
+// the other paths and the ones looking like a file get a 404.
+type RoutesConfig struct {
+	API    []string `yaml:"api" env-default:"/api"`
+	Client []string `yaml:"client" env-default:"/"`
+}
+
+// PagesConfig sets the publication of pages.
+// PreviewSecret signs the preview links of unpublished pages, they expire after PreviewTTL.
+// ScheduleInterval is the longest wait of the daemon publishing and archiving the scheduled pages.
+type PagesConfig struct {
+	PreviewSecret    string        `yaml:"preview_secret"`
+	PreviewTTL       time.Duration `yaml:"preview_ttl" env-default:"24h"`
+	ScheduleInterval time.Duration `yaml:"schedule_interval" env-default:"1m"`
+}
+
+// LanguagesConfig lists the languages of the site, pages are served in the first supported language
+// asked by the URL prefix, the lang query parameter, the lang cookie or Accept-Language, then in Default.
+// Fallbacks are the languages tried after one before the default, f.e. uk: [ru].
+type LanguagesConfig struct {
+	Default   string              `yaml:"default" env-default:"en"`
+	Supported []string            `yaml:"supported" env-default:"en"`
+	Fallbacks map[string][]string `yaml:"fallbacks"`
+}
+
+// SEOConfig sets the links given to search engines.
+// BaseURL starts the absolute URLs of the pages and the sitemap, the host of the request is used when it's empty.
+// Robots replaces the content of public/robots.txt served at /robots.txt.
+type SEOConfig struct {
+	BaseURL string `yaml:"base_url"`
+	Robots  string `yaml:"robots"`
+}
+
+// MediaConfig sets the uploads of the media library, the local driver writes them in Dir.
+// Files larger than MaxSize bytes or of a type not in Types are rejected,
+// images get a resized variant for each of Widths smaller than them.
+type MediaConfig struct {
+	Driver  string   `yaml:"driver" env-default:"local"`
+	Dir     string   `yaml:"dir" env-default:"storage/media"`
+	MaxSize int64    `yaml:"max_size" env-default:"10485760"`
+	Types   []string `yaml:"types" env-default:"image/jpeg,image/png,image/gif,image/webp,application/pdf"`
+	Widths  []int    `yaml:"widths"`
+}
+
+// LogConfig sets the minimum level of the messages written to the log
+type LogConfig struct {
+	Level string `yaml:"level" env-default:"info"`
+}
+
+// Profiles are the built-in settings of each environment,
+// they override the base config file and are overridden by the environment overlay
+var Profiles = map[string]map[string]interface{}{
+	Development: {
+		"embed": false,
+	},
+	Test: {
+		"database.driver": "memory",
+		"database.pool":   1,
+		"secrets.driver":  SecretsFake,
+		"daemons.enabled": false,
+	},
+}
+
+// Env returns the environment given by the flag, SONIC_ENV or development
+func Env(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	if env := os.Getenv(EnvVariable); env != "" {
+		return env
+	}
+	return Development
+}
+
+// ProfilePath returns the overlay of the config file for an environment, f.e. config/app.test.yml
+func ProfilePath(file, env string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "." + env + ext
+}
+
+// Load merges the configuration sources into cfg, from lowest to highest priority:
+// env-default tags, the config file, the profile, the environment overlay of the config file,
+// SONIC_* environment variables, Vault and the overrides given as path=value
+func Load(cfg *Config, file, env string, overrides []string) (sources.Origins, error) {
+	profile := map[string]interface{}{"env": env}
+	for path, value := range Profiles[env] {
+		profile[path] = value
+	}
+
+	base := []sources.Source{
+		sources.DefaultSource(cfg),
+		sources.FileSource(file),
+		sources.MapSource("profile:"+env, profile),
+		sources.OptionalFileSource(ProfilePath(file, env)),
+		sources.EnvSource("SONIC_", cfg),
+	}
+	flags := sources.FlagSource(overrides)
+
+	origins, err := sources.Read(cfg, append(base, flags)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vault settings are only known once the other sources are read
+	if cfg.Vault.Token == "" || cfg.Secrets.Driver == SecretsFake {
+		return origins, nil
+	}
+	store, err := secrets.NewStore(cfg.Vault.Addr, cfg.Vault.Token, cfg.DeploymentID)
+	if err != nil {
+		log.Printf("WARN: Vault config source is disabled: %s", err.Error())
+		return origins, nil
+	}
+	vaultOrigins, err := sources.Read(cfg, append(base, sources.VaultSource(store, "sonic", "private"), flags)...)
+	if err != nil {
+		log.Printf("WARN: Vault config source is disabled: %s", err.Error())
+		return origins, nil
+	}
+	return vaultOrigins, nil
+}
@@ -0,0 +1,63 @@
+package settings
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv(t *testing.T) {
+	os.Unsetenv(EnvVariable)
+	assert.Equal(t, Development, Env(""))
+
+	os.Setenv(EnvVariable, Production)
+	defer os.Unsetenv(EnvVariable)
+	assert.Equal(t, Production, Env(""))
+	assert.Equal(t, Test, Env(Test))
+}
+
+func TestProfilePath(t *testing.T) {
+	assert.Equal(t, "config/app.test.yml", ProfilePath("config/app.yml", Test))
+	assert.Equal(t, "app.production.json", ProfilePath("app.json", Production))
+}
+
+func TestLoadProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settings")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "app.yml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("port: 6969\ndatabase:\n  driver: mysql\n  host: db\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(ProfilePath(file, Production), []byte("port: 8080\n"), 0644))
+
+	cfg := Config{}
+	origins, err := Load(&cfg, file, Development, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Development, cfg.Env)
+	assert.Equal(t, "6969", cfg.Port)
+	assert.Equal(t, "mysql", cfg.Database.Driver)
+	assert.Equal(t, SecretsVault, cfg.Secrets.Driver)
+	assert.True(t, cfg.Daemons.Enabled)
+	assert.Equal(t, "default", origins["daemons.enabled"])
+
+	cfg = Config{}
+	origins, err = Load(&cfg, file, Production, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.Equal(t, "db", cfg.Database.Host)
+	assert.Equal(t, "file:"+ProfilePath(file, Production), origins["port"])
+
+	cfg = Config{}
+	origins, err = Load(&cfg, file, Test, []string{"daemons.enabled=true"})
+	require.NoError(t, err)
+	assert.Equal(t, "memory", cfg.Database.Driver)
+	assert.Equal(t, 1, cfg.Database.Pool)
+	assert.Equal(t, SecretsFake, cfg.Secrets.Driver)
+	assert.True(t, cfg.Daemons.Enabled)
+	assert.Equal(t, "profile:test", origins["database.driver"])
+	assert.Equal(t, "flag", origins["daemons.enabled"])
+}
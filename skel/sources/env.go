@@ -0,0 +1,105 @@
+package sources
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+type defaultSource struct {
+	cfg interface{}
+}
+
+// DefaultSource provides the values of the env-default tags of the config structure
+func DefaultSource(cfg interface{}) Source {
+	return &defaultSource{cfg: cfg}
+}
+
+func (s *defaultSource) Name() string {
+	return "default"
+}
+
+func (s *defaultSource) Load() (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	walkFields(reflect.TypeOf(s.cfg), "", func(path string, field reflect.StructField) {
+		if def, ok := field.Tag.Lookup("env-default"); ok {
+			setPath(values, path, fieldValue(field, def))
+		}
+	})
+	return values, nil
+}
+
+type envSource struct {
+	prefix string
+	cfg    interface{}
+}
+
+// EnvSource reads the environment variables named after the field path with the given prefix,
+// f.e. SONIC_DATABASE_HOST for database.host. The names listed in the env tag of a field
+// (DATABASE_HOST) are still read but have a lower priority.
+func EnvSource(prefix string, cfg interface{}) Source {
+	return &envSource{prefix: prefix, cfg: cfg}
+}
+
+func (s *envSource) Name() string {
+	return "env"
+}
+
+func (s *envSource) Load() (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	walkFields(reflect.TypeOf(s.cfg), "", func(path string, field reflect.StructField) {
+		names := []string{EnvName(s.prefix, path)}
+		if tag, ok := field.Tag.Lookup("env"); ok && tag != "" {
+			names = append(names, strings.Split(tag, ",")...)
+		}
+		for _, name := range names {
+			if value, ok := os.LookupEnv(name); ok {
+				setPath(values, path, fieldValue(field, value))
+				return
+			}
+		}
+	})
+	return values, nil
+}
+
+// fieldValue converts a tag or variable value for the field, lists are separated by commas
+// and their items converted by Read
+func fieldValue(field reflect.StructField, value string) interface{} {
+	if field.Type.Kind() != reflect.Slice {
+		return value
+	}
+	return splitList(value)
+}
+
+// splitList returns the non empty items of a comma-separated list
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// EnvName returns the environment variable name of a field path, f.e. SONIC_MNGAPI_JWT_ISSUER
+func EnvName(prefix, path string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+
+	var prev rune
+	for _, r := range path {
+		switch {
+		case r == '.':
+			b.WriteRune('_')
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			b.WriteRune('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+		prev = r
+	}
+	return b.String()
+}
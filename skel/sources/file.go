@@ -0,0 +1,58 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+type fileSource struct {
+	path     string
+	optional bool
+}
+
+// FileSource reads a yaml, json or toml file, the format is detected from the extension
+func FileSource(path string) Source {
+	return &fileSource{path: path}
+}
+
+// OptionalFileSource is a FileSource providing no values when the file does not exist
+func OptionalFileSource(path string) Source {
+	return &fileSource{path: path, optional: true}
+}
+
+func (s *fileSource) Name() string {
+	return "file:" + s.path
+}
+
+func (s *fileSource) Load() (map[string]interface{}, error) {
+	raw, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if s.optional && os.IsNotExist(err) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(s.path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &values)
+	case ".json":
+		err = json.Unmarshal(raw, &values)
+	case ".toml":
+		err = toml.Unmarshal(raw, &values)
+	default:
+		return nil, fmt.Errorf("file format '%s' is not supported", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return normalize(values).(map[string]interface{}), nil
+}
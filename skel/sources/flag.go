@@ -0,0 +1,53 @@
+package sources
+
+import (
+	"fmt"
+	"strings"
+)
+
+type flagSource struct {
+	overrides []string
+}
+
+// FlagSource provides values given on the command line as "path=value", f.e. "database.host=db"
+func FlagSource(overrides []string) Source {
+	return &flagSource{overrides: overrides}
+}
+
+func (s *flagSource) Name() string {
+	return "flag"
+}
+
+func (s *flagSource) Load() (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, override := range s.overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid override %q, expected path=value", override)
+		}
+		setPath(values, parts[0], parts[1])
+	}
+	return values, nil
+}
+
+type mapSource struct {
+	name   string
+	values map[string]interface{}
+}
+
+// MapSource provides static values keyed by path, f.e. {"database.driver": "memory"}
+func MapSource(name string, values map[string]interface{}) Source {
+	return &mapSource{name: name, values: values}
+}
+
+func (s *mapSource) Name() string {
+	return s.name
+}
+
+func (s *mapSource) Load() (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for path, value := range s.values {
+		setPath(values, path, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,210 @@
+package sources
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces the values of sensitive fields
+const redactedValue = "******"
+
+// Entry is a resolved configuration value with its origin
+type Entry struct {
+	Path   string      `json:"path"`
+	Value  interface{} `json:"value"`
+	Origin string      `json:"origin"`
+}
+
+// Check validates a single configuration value
+type Check func(value interface{}) error
+
+// Rules lists the checks to run on each value path
+type Rules map[string][]Check
+
+// Entries returns every configuration value in field order.
+// Non empty values of the redacted paths, or of paths ending with one of them, are hidden.
+func Entries(cfg interface{}, origins Origins, redacted ...string) []Entry {
+	entries := []Entry{}
+	walkValues(reflect.ValueOf(cfg), "", func(path string, value reflect.Value) {
+		entry := Entry{
+			Path:   path,
+			Value:  value.Interface(),
+			Origin: origins[path],
+		}
+		if entry.Origin == "" {
+			entry.Origin = "-"
+		}
+		if isRedacted(path, redacted) && !value.IsZero() {
+			entry.Value = redactedValue
+		}
+		entries = append(entries, entry)
+	})
+	return entries
+}
+
+// Validate runs the rules against the configuration and returns all the problems found
+func Validate(cfg interface{}, rules Rules) []error {
+	values := map[string]interface{}{}
+	walkValues(reflect.ValueOf(cfg), "", func(path string, value reflect.Value) {
+		values[path] = value.Interface()
+	})
+
+	errs := []error{}
+	for _, path := range sortedPaths(rules) {
+		value, ok := values[path]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown configuration value", path))
+			continue
+		}
+		for _, check := range rules[path] {
+			if err := check(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s", path, err))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// Required fails on zero values
+func Required(value interface{}) error {
+	if reflect.ValueOf(value).IsZero() {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+// URL fails on non empty values which are not absolute URLs
+func URL(value interface{}) error {
+	raw := fmt.Sprint(value)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %s", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: scheme and host are required", raw)
+	}
+	return nil
+}
+
+// Port fails on non empty values out of the 1-65535 range
+func Port(value interface{}) error {
+	raw := fmt.Sprint(value)
+	if raw == "" {
+		return nil
+	}
+	port, err := strconv.Atoi(raw)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %q, expected 1-65535", raw)
+	}
+	return nil
+}
+
+// Positive fails on numbers and durations lower or equal to zero
+func Positive(value interface{}) error {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() > 0 {
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > 0 {
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if v.Float() > 0 {
+			return nil
+		}
+	default:
+		return fmt.Errorf("%T is not a number", value)
+	}
+	return fmt.Errorf("must be positive, got %v", value)
+}
+
+// Paths fails on strings, or items of string lists, which don't start with a slash
+func Paths(value interface{}) error {
+	paths, ok := value.([]string)
+	if !ok {
+		paths = []string{fmt.Sprint(value)}
+	}
+	for _, p := range paths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("invalid path %q, must start with /", p)
+		}
+	}
+	return nil
+}
+
+// OneOf fails on non empty values which are not in the list
+func OneOf(allowed ...string) Check {
+	return func(value interface{}) error {
+		raw := fmt.Sprint(value)
+		if raw == "" {
+			return nil
+		}
+		for _, a := range allowed {
+			if raw == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid value %q, expected one of %s", raw, strings.Join(allowed, ", "))
+	}
+}
+
+func isRedacted(path string, redacted []string) bool {
+	for _, r := range redacted {
+		if path == r || strings.HasSuffix(path, "."+r) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedPaths(rules Rules) []string {
+	paths := make([]string, 0, len(rules))
+	for path := range rules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// walkValues calls fn for every leaf value of a config structure with its yaml path
+func walkValues(v reflect.Value, prefix string, fn func(path string, value reflect.Value)) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := yamlName(field)
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(field.Tag.Get("yaml"), ",inline") {
+			walkValues(v.Field(i), prefix, fn)
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			walkValues(v.Field(i), prefix+name+".", fn)
+			continue
+		}
+		fn(prefix+name, v.Field(i))
+	}
+}
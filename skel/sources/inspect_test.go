@@ -0,0 +1,52 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntries(t *testing.T) {
+	cfg := testConfig{Port: "6969", DeploymentID: "opendax_uat"}
+	cfg.Database.Host = "db"
+
+	entries := Entries(&cfg, Origins{"port": "flag", "database.host": "env"}, "host", "deploymentID", "debug")
+	require.Len(t, entries, 7)
+	assert.Equal(t, Entry{Path: "port", Value: "6969", Origin: "flag"}, entries[0])
+	assert.Equal(t, Entry{Path: "database.host", Value: "******", Origin: "env"}, entries[1])
+	assert.Equal(t, Entry{Path: "database.pool", Value: 0, Origin: "-"}, entries[2])
+	assert.Equal(t, Entry{Path: "deploymentID", Value: "******", Origin: "-"}, entries[3])
+	assert.Equal(t, Entry{Path: "debug", Value: false, Origin: "-"}, entries[4])
+}
+
+func TestValidate(t *testing.T) {
+	rules := Rules{
+		"port":          {Required, Port},
+		"database.host": {URL},
+		"deploymentID":  {Required, OneOf("opendax_uat", "opendax_prod")},
+		"missing":       {Required},
+	}
+
+	cfg := testConfig{Port: "6969", DeploymentID: "opendax_uat"}
+	cfg.Database.Host = "http://db:3306"
+	errs := Validate(&cfg, Rules{"port": rules["port"], "database.host": rules["database.host"], "deploymentID": rules["deploymentID"]})
+	assert.Empty(t, errs)
+
+	cfg = testConfig{Port: "70000", DeploymentID: "other"}
+	cfg.Database.Host = "db"
+	errs = Validate(&cfg, rules)
+	require.Len(t, errs, 4)
+	assert.Contains(t, errs[0].Error(), "database.host: invalid URL")
+	assert.Contains(t, errs[1].Error(), "deploymentID: invalid value")
+	assert.Equal(t, "missing: unknown configuration value", errs[2].Error())
+	assert.Contains(t, errs[3].Error(), "port: invalid port")
+
+	errs = Validate(&testConfig{Hosts: []string{"/api", "app"}}, Rules{"hosts": {Paths}})
+	require.Len(t, errs, 1)
+	assert.Equal(t, `hosts: invalid path "app", must start with /`, errs[0].Error())
+
+	errs = Validate(&testConfig{}, Rules{"port": {Required, Port}})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "port: is required", errs[0].Error())
+}
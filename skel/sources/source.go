@@ -0,0 +1,279 @@
+package sources
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Source loads configuration values as a tree keyed by the yaml names of the config fields,
+// f.e. {"database": {"host": "localhost"}}
+type Source interface {
+	// Name identifies the source in the recorded origins
+	Name() string
+	// Load returns the values provided by the source
+	Load() (map[string]interface{}, error)
+}
+
+// Origins maps every resolved value path, f.e. "database.host", to the name of the source it comes from
+type Origins map[string]string
+
+// Paths returns the recorded paths in alphabetical order
+func (o Origins) Paths() []string {
+	paths := make([]string, 0, len(o))
+	for path := range o {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Read merges the sources from left to right into cfg, so the last source wins,
+// and returns the origin of each resolved value
+func Read(cfg interface{}, sources ...Source) (Origins, error) {
+	types := map[string]reflect.Type{}
+	walkFields(reflect.TypeOf(cfg), "", func(path string, field reflect.StructField) {
+		types[path] = field.Type
+	})
+
+	tree := map[string]interface{}{}
+	origins := Origins{}
+	for _, src := range sources {
+		values, err := src.Load()
+		if err != nil {
+			return nil, fmt.Errorf("config source %s: %w", src.Name(), err)
+		}
+		merge(tree, values)
+		for path := range flatten(values) {
+			if field, ok := fieldPath(types, path); ok {
+				origins[field] = src.Name()
+			}
+		}
+	}
+
+	for path, value := range flatten(tree) {
+		t, ok := leafType(types, path)
+		if !ok {
+			continue
+		}
+		converted, err := convert(value, t)
+		if err != nil {
+			field, _ := fieldPath(types, path)
+			return nil, fmt.Errorf("config value %s from %s: %w", path, origins[field], err)
+		}
+		setPath(tree, path, converted)
+	}
+
+	raw, err := yaml.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(raw, cfg); err != nil {
+		return nil, err
+	}
+	return origins, nil
+}
+
+// fieldPath returns the path of the field holding a leaf, the leaves of map fields are below their field,
+// f.e. "languages.fallbacks.uk" is in "languages.fallbacks"
+func fieldPath(types map[string]reflect.Type, path string) (string, bool) {
+	for {
+		if _, ok := types[path]; ok {
+			return path, true
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return "", false
+		}
+		path = path[:i]
+	}
+}
+
+// leafType returns the type of the value at path, the leaves of map fields have the element type of the map,
+// f.e. []string for "languages.fallbacks.uk"
+func leafType(types map[string]reflect.Type, path string) (reflect.Type, bool) {
+	field, ok := fieldPath(types, path)
+	if !ok {
+		return nil, false
+	}
+	t := types[field]
+	for _, key := range strings.Split(strings.TrimPrefix(path, field), ".")[1:] {
+		if t.Kind() != reflect.Map || key == "" {
+			return nil, false
+		}
+		t = t.Elem()
+	}
+	return t, true
+}
+
+// merge copies src values into dst, nested maps are merged and nil values ignored
+func merge(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if value == nil {
+			continue
+		}
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			merge(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			copied := map[string]interface{}{}
+			merge(copied, srcMap)
+			value = copied
+		}
+		dst[key] = value
+	}
+}
+
+// flatten returns the non nil leaves of a tree indexed by their dotted path
+func flatten(tree map[string]interface{}) map[string]interface{} {
+	leaves := map[string]interface{}{}
+	var walk func(prefix string, node map[string]interface{})
+	walk = func(prefix string, node map[string]interface{}) {
+		for key, value := range node {
+			path := prefix + key
+			if child, ok := value.(map[string]interface{}); ok {
+				walk(path+".", child)
+			} else if value != nil {
+				leaves[path] = value
+			}
+		}
+	}
+	walk("", tree)
+	return leaves
+}
+
+// setPath sets a value in the tree creating the intermediate maps
+func setPath(tree map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	node := tree
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := node[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			node[key] = child
+		}
+		node = child
+	}
+	node[keys[len(keys)-1]] = value
+}
+
+// normalize converts the map[interface{}]interface{} produced by yaml into map[string]interface{}
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			res[fmt.Sprint(key)] = normalize(val)
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			res[key] = normalize(val)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, val := range v {
+			res[i] = normalize(val)
+		}
+		return res
+	default:
+		return value
+	}
+}
+
+// walkFields calls fn for every leaf field of a config structure with its yaml path
+func walkFields(t reflect.Type, prefix string, fn func(path string, field reflect.StructField)) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := yamlName(field)
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(field.Tag.Get("yaml"), ",inline") {
+			walkFields(field.Type, prefix, fn)
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			walkFields(field.Type, prefix+name+".", fn)
+			continue
+		}
+		fn(prefix+name, field)
+	}
+}
+
+// yamlName returns the key used by yaml.v2 for a field
+func yamlName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// convert turns scalar values, usually strings from env, flags or Vault, into the type of the target field.
+// Strings given for a list are separated by commas.
+func convert(value interface{}, t reflect.Type) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		return value, nil
+	case string:
+		if t.Kind() == reflect.Slice {
+			return convert(splitList(v), t)
+		}
+	case []string:
+		// Lists read from tags and variables are strings, their items are converted like single values
+		if t.Kind() != reflect.Slice {
+			return value, nil
+		}
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			converted, err := convert(item, t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return items, nil
+	}
+
+	raw := fmt.Sprint(value)
+	// yaml parses durations from strings like "30s"
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return raw, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(raw, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(raw, 64)
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,190 @@
+package sources
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Port     string `env:"APP_PORT" env-default:"6009"`
+	Database struct {
+		Host string `yaml:"host" env:"DATABASE_HOST" env-default:"localhost"`
+		Pool int    `yaml:"pool" env:"DATABASE_POOL"`
+	} `yaml:"database"`
+	DeploymentID string        `yaml:"deploymentID" env:"DEPLOYMENT_ID"`
+	Debug        bool          `yaml:"debug"`
+	Interval     time.Duration `yaml:"interval"`
+	Hosts        []string      `yaml:"hosts" env-default:"a, b"`
+}
+
+type staticSource struct {
+	name   string
+	values map[string]interface{}
+}
+
+func (s *staticSource) Name() string {
+	return s.name
+}
+
+func (s *staticSource) Load() (map[string]interface{}, error) {
+	return s.values, nil
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestReadPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sources")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "app.yml", "port: 6969\ndatabase:\n  host: db\n  pool: 5\ndeploymentID: opendax_uat\n")
+	os.Setenv("SONIC_DATABASE_POOL", "10")
+	os.Setenv("DEPLOYMENT_ID", "from_legacy_env")
+	defer os.Unsetenv("SONIC_DATABASE_POOL")
+	defer os.Unsetenv("DEPLOYMENT_ID")
+
+	cfg := testConfig{}
+	origins, err := Read(&cfg,
+		DefaultSource(&cfg),
+		FileSource(file),
+		EnvSource("SONIC_", &cfg),
+		&staticSource{name: "vault", values: map[string]interface{}{"debug": true}},
+		FlagSource([]string{"interval=30s", "database.host=flag-db"}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "6969", cfg.Port)
+	assert.Equal(t, "flag-db", cfg.Database.Host)
+	assert.Equal(t, 10, cfg.Database.Pool)
+	assert.Equal(t, "from_legacy_env", cfg.DeploymentID)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, 30*time.Second, cfg.Interval)
+
+	assert.Equal(t, Origins{
+		"port":          "file:" + file,
+		"database.host": "flag",
+		"database.pool": "env",
+		"deploymentID":  "env",
+		"debug":         "vault",
+		"interval":      "flag",
+		"hosts":         "default",
+	}, origins)
+	assert.Equal(t, []string{"database.host", "database.pool", "debug", "deploymentID", "hosts", "interval", "port"}, origins.Paths())
+}
+
+func TestReadMapField(t *testing.T) {
+	cfg := struct {
+		Fallbacks map[string][]string `yaml:"fallbacks"`
+	}{}
+	origins, err := Read(&cfg,
+		&staticSource{name: "file", values: map[string]interface{}{"fallbacks": map[string]interface{}{"uk": []interface{}{"ru"}}}},
+		&staticSource{name: "vault", values: map[string]interface{}{"fallbacks": map[string]interface{}{"be": []interface{}{"ru"}}}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"uk": {"ru"}, "be": {"ru"}}, cfg.Fallbacks)
+	assert.Equal(t, Origins{"fallbacks": "vault"}, origins)
+}
+
+func TestReadFlagLists(t *testing.T) {
+	cfg := struct {
+		Supported []string            `yaml:"supported"`
+		Widths    []int               `yaml:"widths"`
+		Fallbacks map[string][]string `yaml:"fallbacks"`
+	}{}
+	origins, err := Read(&cfg,
+		&staticSource{name: "file", values: map[string]interface{}{"fallbacks": map[string]interface{}{"be": []interface{}{"ru"}}}},
+		&staticSource{name: "vault", values: map[string]interface{}{"widths": "320, 640"}},
+		FlagSource([]string{"supported=en,ru", "fallbacks.uk=ru,en"}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en", "ru"}, cfg.Supported)
+	assert.Equal(t, []int{320, 640}, cfg.Widths)
+	assert.Equal(t, map[string][]string{"be": {"ru"}, "uk": {"ru", "en"}}, cfg.Fallbacks)
+	assert.Equal(t, Origins{"supported": "flag", "widths": "vault", "fallbacks": "flag"}, origins)
+
+	_, err = Read(&cfg, FlagSource([]string{"widths=320,x"}))
+	assert.EqualError(t, err, `config value widths from flag: strconv.ParseInt: parsing "x": invalid syntax`)
+}
+
+func TestReadDefaults(t *testing.T) {
+	cfg := testConfig{}
+	origins, err := Read(&cfg, DefaultSource(&cfg))
+	require.NoError(t, err)
+
+	assert.Equal(t, "6009", cfg.Port)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, "default", origins["port"])
+
+	os.Setenv("SONIC_HOSTS", "c,d")
+	defer os.Unsetenv("SONIC_HOSTS")
+	_, err = Read(&cfg, DefaultSource(&cfg), EnvSource("SONIC_", &cfg))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, cfg.Hosts)
+}
+
+func TestReadIntList(t *testing.T) {
+	cfg := struct {
+		Widths []int `yaml:"widths" env-default:"320, 768"`
+	}{}
+	_, err := Read(&cfg, DefaultSource(&cfg))
+	require.NoError(t, err)
+	assert.Equal(t, []int{320, 768}, cfg.Widths)
+
+	os.Setenv("SONIC_WIDTHS", "100,x")
+	defer os.Unsetenv("SONIC_WIDTHS")
+	_, err = Read(&cfg, DefaultSource(&cfg), EnvSource("SONIC_", &cfg))
+	assert.EqualError(t, err, `config value widths from env: strconv.ParseInt: parsing "x": invalid syntax`)
+}
+
+func TestFileFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sources")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		writeFile(t, dir, "app.yaml", "database:\n  host: yaml\n  pool: 3\n"),
+		writeFile(t, dir, "app.json", `{"database": {"host": "json", "pool": 3}}`),
+		writeFile(t, dir, "app.toml", "[database]\nhost = \"toml\"\npool = 3\n"),
+	}
+	for _, file := range files {
+		cfg := testConfig{}
+		_, err := Read(&cfg, FileSource(file))
+		require.NoError(t, err, file)
+		assert.Equal(t, filepath.Ext(file)[1:], cfg.Database.Host)
+		assert.Equal(t, 3, cfg.Database.Pool)
+	}
+
+	cfg := testConfig{}
+	_, err = Read(&cfg, FileSource(writeFile(t, dir, "app.ini", "")))
+	assert.Error(t, err)
+	_, err = Read(&cfg, FileSource(filepath.Join(dir, "missing.yml")))
+	assert.Error(t, err)
+	_, err = Read(&cfg, OptionalFileSource(filepath.Join(dir, "missing.yml")))
+	assert.NoError(t, err)
+}
+
+func TestReadInvalidValue(t *testing.T) {
+	cfg := testConfig{}
+	_, err := Read(&cfg, FlagSource([]string{"database.pool=many"}))
+	assert.Error(t, err)
+
+	_, err = Read(&cfg, FlagSource([]string{"database.pool"}))
+	assert.Error(t, err)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "SONIC_PORT", EnvName("SONIC_", "port"))
+	assert.Equal(t, "SONIC_DEPLOYMENT_ID", EnvName("SONIC_", "deploymentID"))
+	assert.Equal(t, "SONIC_MNGAPI_JWT_PRIVATE_KEY", EnvName("SONIC_", "mngapi.jwt_private_key"))
+}
@@ -0,0 +1,40 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/openware/sonic/skel/secrets"
+)
+
+type vaultSource struct {
+	store     *secrets.Store
+	component string
+	scope     string
+}
+
+// VaultSource reads the latest version of a component scope in Vault KV,
+// keys are field paths like "opendax.addr". A missing scope provides no values.
+func VaultSource(store *secrets.Store, component, scope string) Source {
+	return &vaultSource{store: store, component: component, scope: scope}
+}
+
+func (s *vaultSource) Name() string {
+	return fmt.Sprintf("vault:%s.%s", s.component, s.scope)
+}
+
+func (s *vaultSource) Load() (map[string]interface{}, error) {
+	data, err := s.store.ReadVersion(s.component, s.scope, 0)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	for path, value := range data {
+		setPath(values, path, value)
+	}
+	return values, nil
+}
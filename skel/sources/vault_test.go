@@ -0,0 +1,43 @@
+//go:build fakevault
+// +build fakevault
+
+package sources
+
+import (
+	"testing"
+
+	"github.com/openware/kaigara/pkg/vault"
+	"github.com/openware/sonic/skel/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultSource(t *testing.T) {
+	fake, err := secrets.NewFakeVault()
+	require.NoError(t, err)
+	defer fake.Close()
+
+	service := vault.NewService(fake.Addr(), "fake", "opendax_uat")
+	require.NoError(t, service.LoadSecrets("sonic", "private"))
+	require.NoError(t, service.SetSecret("sonic", "database.pool", "8", "private"))
+	require.NoError(t, service.SetSecret("sonic", "hosts", "a,b", "private"))
+	require.NoError(t, service.SetSecret("sonic", "fallbacks.uk", "ru", "private"))
+	require.NoError(t, service.SaveSecrets("sonic", "private"))
+
+	store, err := secrets.NewStore(fake.Addr(), "fake", "opendax_uat")
+	require.NoError(t, err)
+
+	cfg := struct {
+		Database struct {
+			Pool int `yaml:"pool"`
+		} `yaml:"database"`
+		Hosts     []string            `yaml:"hosts"`
+		Fallbacks map[string][]string `yaml:"fallbacks"`
+	}{}
+	origins, err := Read(&cfg, VaultSource(store, "sonic", "private"), VaultSource(store, "sonic", "missing"))
+	require.NoError(t, err)
+	assert.Equal(t, 8, cfg.Database.Pool)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, map[string][]string{"uk": {"ru"}}, cfg.Fallbacks)
+	assert.Equal(t, "vault:sonic.private", origins["fallbacks"])
+}